@@ -0,0 +1,611 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+)
+
+type command func(args []string, stdout io.Writer, stderr io.Writer) error
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"status":   statusCommand,
+		"move":     moveCommand,
+		"jog":      jogCommand,
+		"home":     homeCommand,
+		"stop":     stopCommand,
+		"kill":     killCommand,
+		"config":   configCommand,
+		"send":     sendCommand,
+		"discover": discoverCommand,
+		"shell":    shellCommand,
+	}
+}
+
+/*
+Returns a context that is cancelled on interrupt and after
+the timeout, when it is greater than zero
+*/
+func interruptible(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+type statusReport struct {
+	Channel    uint                       `json:"channel"`
+	Indexer    protocol.IndexerStatus     `json:"indexer"`
+	Position   int                        `json:"position"`
+	Limits     protocol.LimitStatus       `json:"limits"`
+	ClosedLoop *protocol.ClosedLoopStatus `json:"closed_loop,omitempty"`
+	Execution  protocol.ExecutionStatus   `json:"execution"`
+	Inputs     protocol.InputStatus       `json:"inputs"`
+}
+
+/*
+Reports the status of the channel
+*/
+func statusCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("status", "status [flags]", stdout, stderr)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	report := statusReport{Channel: o.channel}
+	if report.Indexer, err = parker.GetIndexerStatus(o.channel); err != nil {
+		return err
+	}
+	if report.Limits, err = parker.ReadLimitStatus(o.channel); err != nil {
+		return err
+	}
+	if report.Execution, err = parker.GetExecutionStatus(o.channel); err != nil {
+		return err
+	}
+	if report.Inputs, err = parker.GetInputStatus(o.channel); err != nil {
+		return err
+	}
+	if busy(report.Indexer) {
+		if report.Position, err = parker.GetRelativePosition(o.channel); err != nil {
+			return err
+		}
+	} else {
+		closedLoop, err := parker.ReadClosedLoopStatus(o.channel)
+		if err != nil {
+			return err
+		}
+		report.ClosedLoop = &closedLoop
+		if report.Position, err = parker.GetAbsolutePosition(o.channel); err != nil {
+			return err
+		}
+	}
+
+	return o.print(report, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		defer tw.Flush()
+
+		fmt.Fprintf(tw, "Channel:\t%d\n", report.Channel)
+		fmt.Fprintf(tw, "Indexer:\t%s\n", describeIndexer(report.Indexer))
+		if busy(report.Indexer) {
+			fmt.Fprintf(tw, "Position:\t%d (from start of move)\n", report.Position)
+		} else {
+			fmt.Fprintf(tw, "Position:\t%d\n", report.Position)
+		}
+		fmt.Fprintf(tw, "Limits:\t%s\n", describeLimits(report.Limits))
+		if report.ClosedLoop != nil {
+			fmt.Fprintf(tw, "Closed loop:\t%s\n", describeClosedLoop(*report.ClosedLoop))
+		} else {
+			fmt.Fprintf(tw, "Closed loop:\t%s\n", unavailableWhileBusy)
+		}
+		fmt.Fprintf(tw, "Execution:\t%s\n", describeExecution(report.Execution))
+		fmt.Fprintf(tw, "Inputs:\t%s\n", describeInputs(report.Inputs))
+	})
+}
+
+/*
+Shown instead of the closed loop status while the indexer is
+busy, since RC is buffered and only answered when the move ends
+*/
+const unavailableWhileBusy = "unavailable while busy"
+
+/*
+Returns true while the indexer is executing commands, when
+the drive does not answer the buffered reports (PR, RC)
+*/
+func busy(status protocol.IndexerStatus) bool {
+	return status == protocol.IndexerBusy || status == protocol.IndexerBusyAttention
+}
+
+func describeIndexer(status protocol.IndexerStatus) string {
+	switch status {
+	case protocol.IndexerReady:
+		return "ready"
+	case protocol.IndexerReadyAttention:
+		return "ready, attention required"
+	case protocol.IndexerBusy:
+		return "busy"
+	case protocol.IndexerBusyAttention:
+		return "busy, attention required"
+	}
+	return string(status)
+}
+
+/*
+Describes a 4-character status with the names of the active flags
+*/
+func describeFlags(flags string, names ...string) string {
+	var active []string
+	for i, name := range names {
+		if i < len(flags) && flags[i] == '1' {
+			active = append(active, name)
+		}
+	}
+	if len(active) == 0 {
+		return flags
+	}
+	return fmt.Sprintf("%s (%s)", flags, strings.Join(active, ", "))
+}
+
+func describeLimits(status protocol.LimitStatus) string {
+	return describeFlags(status.String(), "stopped by CW", "stopped by CCW", "CW active", "CCW active")
+}
+
+func describeClosedLoop(status protocol.ClosedLoopStatus) string {
+	return describeFlags(status.String(), "static position loss", "post move position loss", "homing failed", "stall")
+}
+
+func describeEncoderFunctions(functions protocol.EncoderFunctions) string {
+	return describeFlags(functions.String(), "absolute", "encoder steps", "position maintenance", "stop on stall",
+		"output on stall", "multiple axis stop", "output in dead band", "stall detection")
+}
+
+func describeExecution(status protocol.ExecutionStatus) string {
+	return describeFlags(status.String(), "loop", "pause", "shutdown", "trigger")
+}
+
+func describeInputs(status protocol.InputStatus) string {
+	return fmt.Sprintf("triggers %s, home %s, CW %s, CCW %s, fault %s",
+		levels(status.Triggers[:]...), levels(status.Home),
+		levels(status.CWLimit), levels(status.CCWLimit), yesNo(status.Faulted))
+}
+
+func levels(values ...bool) string {
+	var builder strings.Builder
+	for _, value := range values {
+		if value {
+			builder.WriteByte('1')
+		} else {
+			builder.WriteByte('0')
+		}
+	}
+	return builder.String()
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+type positionReport struct {
+	Channel  uint `json:"channel"`
+	Position int  `json:"position"`
+}
+
+/*
+Prints the absolute position of the channel
+*/
+func printPosition(o *options, parker *protocol.OEM750x) error {
+	position, err := parker.GetAbsolutePosition(o.channel)
+	if err != nil {
+		return err
+	}
+	return o.print(positionReport{Channel: o.channel, Position: position}, func(w io.Writer) {
+		fmt.Fprintf(w, "Channel %d at position %d\n", o.channel, position)
+	})
+}
+
+/*
+Moves to an absolute position or by a relative distance
+*/
+func moveCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("move", "move (-to POSITION | -by STEPS) [flags]", stdout, stderr)
+	to := fs.Int("to", 0, "absolute target position in steps")
+	by := fs.Int("by", 0, "distance relative to the current position in steps")
+	velocity := fs.Float64("velocity", 0, "velocity in rps (default keeps the current)")
+	acceleration := fs.Float64("acceleration", 0, "acceleration in rps² (default keeps the current)")
+	timeout := fs.Duration("timeout", time.Minute, "maximum duration of the move")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["to"] == set["by"] {
+		fmt.Fprintln(stderr, "exactly one of -to or -by is required")
+		fs.Usage()
+		return errUsage
+	}
+
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	if set["velocity"] {
+		if err := parker.SetTargetVelocity(o.channel, *velocity); err != nil {
+			return err
+		}
+	}
+	if set["acceleration"] {
+		if err := parker.SetTargetAcceleration(o.channel, *acceleration); err != nil {
+			return err
+		}
+	}
+	ctx, cancel := interruptible(*timeout)
+	defer cancel()
+	if set["to"] {
+		err = parker.MoveTo(ctx, o.channel, *to)
+	} else {
+		err = parker.MoveBy(ctx, o.channel, *by)
+	}
+	if err != nil {
+		return err
+	}
+	return printPosition(o, parker)
+}
+
+/*
+Moves continuously until the duration ends or the command
+is interrupted, then stops the motor
+*/
+func jogCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("jog", "jog [-direction +|-] [-velocity RPS] [-duration D] [flags]", stdout, stderr)
+	direction := fs.String("direction", "+", "direction of the motion: + or -")
+	velocity := fs.Float64("velocity", 0, "velocity in rps (default keeps the current)")
+	duration := fs.Duration("duration", 0, "duration of the motion (default until interrupted)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if *direction != string(protocol.Forward) && *direction != string(protocol.Backward) {
+		fmt.Fprintf(stderr, "invalid direction %q, must be + or -\n", *direction)
+		return errUsage
+	}
+
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	if *velocity != 0 {
+		if err := parker.SetTargetVelocity(o.channel, *velocity); err != nil {
+			return err
+		}
+	}
+	if err := parker.SetContinuosMode(o.channel); err != nil {
+		return err
+	} else if err := parker.SetDirection(o.channel, protocol.Direction(*direction)); err != nil {
+		return err
+	} else if err := parker.Go(o.channel); err != nil {
+		return err
+	}
+
+	ctx, cancel := interruptible(*duration)
+	<-ctx.Done()
+	cancel()
+
+	if err := parker.Stop(o.channel); err != nil {
+		return err
+	}
+	wait, cancel := interruptible(time.Minute)
+	defer cancel()
+	if err := parker.WaitForMove(wait, o.channel); err != nil {
+		return err
+	}
+	if err := parker.SetNormalMode(o.channel); err != nil {
+		return err
+	}
+	return printPosition(o, parker)
+}
+
+/*
+Executes the homing procedure using the end-of-travel limits
+*/
+func homeCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("home", "home [-velocity RPS] [-timeout D] [flags]", stdout, stderr)
+	velocity := fs.Float64("velocity", 1, "homing velocity in rps")
+	timeout := fs.Duration("timeout", 5*time.Minute, "maximum duration of the homing")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	ctx, cancel := interruptible(*timeout)
+	defer cancel()
+	if err := parker.GoHomeHard(ctx, o.channel, *velocity); err != nil {
+		return err
+	}
+	return printPosition(o, parker)
+}
+
+/*
+Decelerates the motor, or every motor with -all, to a stop
+*/
+func stopCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("stop", "stop [-all] [flags]", stdout, stderr)
+	all := fs.Bool("all", false, "stop every motor of the daisy chain")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	if *all {
+		return parker.StopAll()
+	}
+	return parker.Stop(o.channel)
+}
+
+/*
+Ceases the motion immediately
+*/
+func killCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("kill", "kill [flags]", stdout, stderr)
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	return parker.Kill(o.channel)
+}
+
+/*
+Applies a profile to the drive or dumps the drive setup
+*/
+func configCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: oem750x config (apply FILE | dump) [flags]")
+		return errUsage
+	}
+	switch args[0] {
+	case "apply":
+		return configApply(args[1:], stdout, stderr)
+	case "dump":
+		return configDump(args[1:], stdout, stderr)
+	}
+	fmt.Fprintf(stderr, "unknown config command %q, must be apply or dump\n", args[0])
+	return errUsage
+}
+
+type applyReport struct {
+	Applied     []uint                      `json:"applied"`
+	Differences []protocol.ConfigDifference `json:"differences"`
+}
+
+func configApply(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("config apply", "config apply [-verify=false] [flags] FILE", stdout, stderr)
+	verify := fs.Bool("verify", true, "read back the queryable parameters after applying")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	config, err := protocol.LoadConfig(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	ctx, cancel := interruptible(0)
+	defer cancel()
+	if err := parker.Apply(ctx, config.Channels...); err != nil {
+		return err
+	}
+	report := applyReport{Differences: []protocol.ConfigDifference{}}
+	for _, channel := range config.Channels {
+		report.Applied = append(report.Applied, channel.Channel)
+	}
+	if *verify {
+		if report.Differences, err = parker.Verify(ctx, config.Channels...); err != nil {
+			return err
+		}
+	}
+	if err := o.print(report, func(w io.Writer) {
+		fmt.Fprintf(w, "Applied %s to %d channel(s)\n", fs.Arg(0), len(report.Applied))
+		for _, difference := range report.Differences {
+			fmt.Fprintln(w, difference)
+		}
+	}); err != nil {
+		return err
+	}
+	if len(report.Differences) > 0 {
+		return fmt.Errorf("%d parameter(s) differ from the profile", len(report.Differences))
+	}
+	return nil
+}
+
+func configDump(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("config dump", "config dump [-channels 1,2] [flags]", stdout, stderr)
+	channels := fs.String("channels", "", "comma separated channels (default is -channel)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	addresses := []uint{o.channel}
+	if *channels != "" {
+		addresses = nil
+		for _, field := range strings.Split(*channels, ",") {
+			var address uint
+			if _, err := fmt.Sscan(strings.TrimSpace(field), &address); err != nil || address == 0 {
+				return fmt.Errorf("invalid channel %q", field)
+			}
+			addresses = append(addresses, address)
+		}
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	var config protocol.DriveConfig
+	for _, address := range addresses {
+		channel, err := parker.ReadConfig(address)
+		if err != nil {
+			return fmt.Errorf("channel %d: %w", address, err)
+		}
+		config.Channels = append(config.Channels, channel)
+	}
+	data, err := config.YAML()
+	if o.json {
+		data, err = config.JSON()
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(data)
+	return err
+}
+
+/*
+Lists the drives of the daisy chain, optionally assigning
+consecutive addresses before probing them
+*/
+func discoverCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("discover", "discover [-max N] [-assign FIRST] [flags]", stdout, stderr)
+	maxAddress := fs.Uint("max", 8, "highest address probed")
+	assign := fs.Uint("assign", 0, "assign consecutive addresses starting at FIRST before probing")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	if *assign > 0 {
+		count, err := parker.AssignAddresses(*assign)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("no drive took an address")
+		}
+		if !o.json {
+			fmt.Fprintf(stdout, "Assigned addresses %d to %d\n", *assign, *assign+count-1)
+		}
+		*maxAddress = max(*maxAddress, *assign+count-1)
+	}
+	ctx, cancel := interruptible(0)
+	defer cancel()
+	drives, err := parker.Discover(ctx, *maxAddress)
+	if err != nil {
+		return err
+	}
+
+	type driveReport struct {
+		Address    uint   `json:"address"`
+		PartNumber string `json:"part_number"`
+		Revision   string `json:"revision"`
+	}
+	report := []driveReport{}
+	for _, drive := range drives {
+		report = append(report, driveReport(drive))
+	}
+	return o.print(report, func(w io.Writer) {
+		if len(drives) == 0 {
+			fmt.Fprintf(w, "No drives answered from address 1 to %d\n", *maxAddress)
+			return
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		defer tw.Flush()
+		fmt.Fprintln(tw, "ADDRESS\tPART NUMBER\tREVISION")
+		for _, drive := range drives {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", drive.Address, drive.PartNumber, drive.Revision)
+		}
+	})
+}
+
+type sendReport struct {
+	Command  string `json:"command"`
+	Response string `json:"response,omitempty"`
+}
+
+/*
+Sends a raw command through Request and prints the response.
+Commands without response must be sent with -write
+*/
+func sendCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("send", "send [-write] [flags] COMMAND", stdout, stderr)
+	write := fs.Bool("write", false, "send a command without response, checking only the echo")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	report := sendReport{Command: fs.Arg(0)}
+	if *write {
+		err = parker.Write(report.Command)
+	} else {
+		var response []byte
+		response, err = parker.Request(report.Command)
+		report.Response = string(response)
+	}
+	if err != nil {
+		return err
+	}
+	return o.print(report, func(w io.Writer) {
+		if report.Response != "" {
+			fmt.Fprintln(w, report.Response)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+/*
+Command oem750x operates Parker OEM750X drives from the terminal
+
+Usage:
+
+	oem750x <command> [flags] [arguments]
+
+Commands:
+
+	status   reports the indexer, limit, closed loop and input status
+	move     moves to an absolute position or by a relative distance
+	jog      moves continuously until the duration ends or it is interrupted
+	home     executes the homing procedure using the end-of-travel limits
+	stop     decelerates the motor to a stop
+	kill     ceases the motion immediately
+	config   applies a profile to the drive or dumps the drive setup
+	send     sends a raw command and prints the response
+	discover lists the drives of the daisy chain and assigns their addresses
+	shell    opens an interactive shell with history, completion and a status line
+
+Every command accepts the connection flags (-port, -baud, -parity,
+-data-bits, -stop-bits), -channel, -json and -simulate
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: oem750x <command> [flags] [arguments]
+
+Commands:
+  status   reports the indexer, limit, closed loop and input status
+  move     moves to an absolute position (-to) or by a distance (-by)
+  jog      moves continuously until the duration ends or it is interrupted
+  home     executes the homing procedure using the end-of-travel limits
+  stop     decelerates the motor to a stop
+  kill     ceases the motion immediately
+  config   applies a profile (config apply FILE) or dumps the setup (config dump)
+  send     sends a raw command (e.g., send 1V) and prints the response
+  discover lists the drives of the daisy chain (-assign 1 numbers them first)
+  shell    opens an interactive shell with history, completion and a status line
+
+Run 'oem750x <command> -h' for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+/*
+Runs the command line and returns the exit code
+*/
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	command, exists := commands[args[0]]
+	if !exists {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	if err := command(args[1:], stdout, stderr); err != nil {
+		if err == errUsage {
+			return 2
+		}
+		fmt.Fprintf(stderr, "oem750x %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"move", "-simulate", "-json", "-by", "500"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("move exited with %d: %s", code, stderr.String())
+	}
+	var position positionReport
+	if err := json.Unmarshal(stdout.Bytes(), &position); err != nil || position.Position != 500 {
+		t.Fatalf("move output %q, %v", stdout.String(), err)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"status", "-simulate", "-json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("status exited with %d: %s", code, stderr.String())
+	}
+	var status map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &status); err != nil || status["indexer"] != "R" {
+		t.Fatalf("status output %q, %v", stdout.String(), err)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"send", "-simulate", "-channel", "2", "2V"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("send exited with %d: %s", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != "*V1.00" {
+		t.Fatalf("send output %q", stdout.String())
+	}
+
+	if code := run([]string{"move", "-simulate", "-to", "1", "-by", "1"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("conflicting move flags exited with %d", code)
+	}
+	if code := run([]string{"rotate"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("unknown command exited with %d", code)
+	}
+}
+
+func TestShell(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	fs, o := newFlags("shell", "shell", &stdout, &stderr)
+	if err := parse(fs, []string{"-simulate"}); err != nil {
+		t.Fatal(err)
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disconnect()
+
+	s := &shell{parker: parker, channel: 1}
+	script := "v2.5 V\nRA 1PR\nuse 0\nfoo\nexit\nV\n"
+	if err := s.script(strings.NewReader(script), &stdout); err == nil {
+		t.Fatal("expected the failed commands to be reported")
+	}
+	expected := "*V2.50\n*@  (0000)\n*+0000000000  (position 0)\n" +
+		"error: invalid channel \"0\"\nerror: unknown command \"foo\", send it with request or write\n"
+	if stdout.String() != expected {
+		t.Fatalf("shell output %q", stdout.String())
+	}
+	if prompt := s.prompt(true); prompt != "[1 R RA 0000 PR 0]> " {
+		t.Fatalf("prompt %q", prompt)
+	}
+
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	} else if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+	if prompt := s.prompt(true); !strings.HasPrefix(prompt, "[1 B RA 0000 W3 ") {
+		t.Fatalf("prompt during a move %q", prompt)
+	}
+	var status bytes.Buffer
+	if err := s.status(&status); err != nil || !strings.Contains(status.String(), "unavailable while busy") {
+		t.Fatalf("status during a move %q, %v", status.String(), err)
+	}
+	if err := parker.Kill(1); err != nil {
+		t.Fatal(err)
+	}
+
+	completions := []struct {
+		line, completed string
+		candidates      int
+	}{
+		{"us", "use ", 1},
+		{"2X", "2X", 10},
+		{"2XS", "2XS", 3},
+		{"V1 oS", "V1 OS", 4},
+		{"ps", "PS", 1},
+	}
+	for _, c := range completions {
+		line, pos, candidates := complete(c.line, len(c.line))
+		if line != c.completed || pos != len(line) || len(candidates) != c.candidates {
+			t.Fatalf("complete(%q) = %q, %d, %v", c.line, line, pos, candidates)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+)
+
+/*
+When the drive answers a command with a response
+*/
+type reply int
+
+const (
+	replyNever reply = iota
+	replyAlways
+	replyWithoutArgument
+)
+
+/*
+Command of the drive known by the shell, with the typed
+decoder of its response when one exists
+*/
+type mnemonic struct {
+	name    string
+	summary string
+	reply   reply
+	decode  func(value string) (string, error)
+}
+
+var mnemonicList = []mnemonic{
+	{"A", "acceleration in rps²", replyWithoutArgument, nil},
+	{"C", "continue after a pause", replyNever, nil},
+	{"CG", "position maintenance correction gain (1 to 8)", replyWithoutArgument, nil},
+	{"CMDDIR", "commanded direction polarity", replyWithoutArgument, nil},
+	{"D", "distance in steps", replyWithoutArgument, nil},
+	{"DB", "dead band in encoder steps", replyWithoutArgument, nil},
+	{"DW", "dead band window in motor steps", replyWithoutArgument, nil},
+	{"ER", "encoder resolution", replyWithoutArgument, nil},
+	{"FS", "encoder functions", replyAlways, decodeEncoderFunctions},
+	{"FSA", "incremental (0) or absolute (1) positioning", replyNever, nil},
+	{"FSB", "motor steps (0) or encoder steps (1)", replyNever, nil},
+	{"FSC", "position maintenance", replyNever, nil},
+	{"FSD", "stop on stall", replyNever, nil},
+	{"FSH", "stall detection", replyNever, nil},
+	{"G", "go", replyNever, nil},
+	{"GH", "go home with the given velocity", replyNever, nil},
+	{"H", "direction (+ or -)", replyNever, nil},
+	{"IS", "input status", replyAlways, decodeInputs},
+	{"K", "kill", replyNever, nil},
+	{"L", "loop (0 for infinite)", replyNever, nil},
+	{"LD", "limits disable", replyNever, nil},
+	{"MC", "continuous mode", replyNever, nil},
+	{"MN", "normal mode", replyNever, nil},
+	{"MPA", "absolute positioning", replyNever, nil},
+	{"MPI", "incremental positioning", replyNever, nil},
+	{"MR", "motor resolution", replyWithoutArgument, nil},
+	{"N", "end of loop", replyNever, nil},
+	{"O", "set outputs (1, 0 or X)", replyNever, nil},
+	{"OSA", "end-of-travel limits active level", replyNever, nil},
+	{"OSB", "back up to home", replyNever, nil},
+	{"OSC", "home switch active level", replyNever, nil},
+	{"OSH", "home edge", replyNever, nil},
+	{"PR", "absolute position", replyAlways, decodePosition},
+	{"PS", "pause", replyNever, nil},
+	{"PX", "absolute encoder position", replyAlways, decodePosition},
+	{"PZ", "set position to zero", replyNever, nil},
+	{"R", "indexer status", replyAlways, decodeIndexer},
+	{"RA", "limits status", replyAlways, decodeLimits},
+	{"RB", "loop, pause, shutdown and trigger status", replyAlways, decodeExecution},
+	{"RC", "closed loop status", replyAlways, decodeClosedLoop},
+	{"RV", "part number and revision", replyAlways, nil},
+	{"S", "stop", replyNever, nil},
+	{"SSE", "software error checking", replyNever, nil},
+	{"ST", "shutdown", replyWithoutArgument, nil},
+	{"T", "time delay in seconds", replyNever, nil},
+	{"TR", "wait for trigger pattern", replyNever, nil},
+	{"TS", "trigger inputs status", replyAlways, decodeTriggers},
+	{"V", "velocity in rps", replyWithoutArgument, nil},
+	{"W3", "position relative to the start of the move", replyAlways, decodeRelativePosition},
+	{"XC", "sequence memory checksum", replyAlways, nil},
+	{"XD", "define sequence", replyNever, nil},
+	{"XE", "erase sequence", replyNever, nil},
+	{"XR", "run sequence", replyNever, nil},
+	{"XRP", "run sequence on power up", replyNever, nil},
+	{"XSD", "status of the last sequence definition", replyAlways, nil},
+	{"XSR", "status of the last sequence run", replyAlways, decodeSequenceRun},
+	{"XSS", "status of a stored sequence", replyAlways, decodeSequence},
+	{"XT", "end of sequence definition", replyNever, nil},
+	{"XU", "upload sequence", replyAlways, nil},
+	{"Y", "stop loop", replyNever, nil},
+	{"Z", "reset", replyNever, nil},
+}
+
+/*
+Mnemonics sorted from the longest to the shortest, so that
+the prefix matching picks the most specific command
+*/
+var mnemonicsByLength = func() []mnemonic {
+	sorted := append([]mnemonic(nil), mnemonicList...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].name) > len(sorted[j].name)
+	})
+	return sorted
+}()
+
+/*
+Splits a command after the device address into the mnemonic
+and its argument, the mnemonic is nil when it is unknown
+*/
+func splitCommand(command string) (*mnemonic, string) {
+	body := strings.TrimLeft(command, "0123456789")
+	for i := range mnemonicsByLength {
+		if strings.HasPrefix(body, mnemonicsByLength[i].name) {
+			return &mnemonicsByLength[i], body[len(mnemonicsByLength[i].name):]
+		}
+	}
+	return nil, body
+}
+
+/*
+Returns true if the drive answers the command
+*/
+func (m *mnemonic) replies(argument string) bool {
+	return m.reply == replyAlways || (m.reply == replyWithoutArgument && argument == "")
+}
+
+/*
+Describes the response of the command using the typed
+parsers of the protocol package
+*/
+func (m *mnemonic) describe(response string) (string, error) {
+	if m.decode == nil {
+		return "", nil
+	}
+	return m.decode(strings.TrimPrefix(response, "*"))
+}
+
+func decodeIndexer(value string) (string, error) {
+	return describeIndexer(protocol.IndexerStatus(value)), nil
+}
+
+func decodeLimits(value string) (string, error) {
+	status, err := protocol.ParseLimitStatus(value)
+	return describeLimits(status), err
+}
+
+func decodeExecution(value string) (string, error) {
+	status, err := protocol.ParseExecutionStatus(value)
+	return describeExecution(status), err
+}
+
+func decodeClosedLoop(value string) (string, error) {
+	status, err := protocol.ParseClosedLoopStatus(value)
+	return describeClosedLoop(status), err
+}
+
+func decodeInputs(value string) (string, error) {
+	status, err := protocol.ParseInputStatus(value)
+	return fmt.Sprintf("%s, address %d", describeInputs(status), status.Address), err
+}
+
+func decodeTriggers(value string) (string, error) {
+	status, err := protocol.ParseTriggerStatus(value)
+	return "triggers " + status.String(), err
+}
+
+func decodeEncoderFunctions(value string) (string, error) {
+	functions, err := protocol.ParseEncoderFunctions(value)
+	return describeEncoderFunctions(functions), err
+}
+
+func decodePosition(value string) (string, error) {
+	position, err := strconv.Atoi(value)
+	return fmt.Sprintf("position %d", position), err
+}
+
+func decodeRelativePosition(value string) (string, error) {
+	position, err := strconv.ParseUint(value, 16, 32)
+	return fmt.Sprintf("%d from the start of the move", int32(uint32(position))), err
+}
+
+func decodeSequence(value string) (string, error) {
+	status, err := strconv.Atoi(value)
+	return protocol.SequenceStatus(status).String(), err
+}
+
+func decodeSequenceRun(value string) (string, error) {
+	status, err := strconv.Atoi(value)
+	return protocol.SequenceRunStatus(status).String(), err
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+	"github.com/devicehub-go/unicomm"
+	"github.com/devicehub-go/unicomm/protocol/unicommserial"
+)
+
+var errUsage = errors.New("invalid usage")
+
+/*
+Connection and output flags shared by every command
+*/
+type options struct {
+	port     string
+	baud     int
+	parity   string
+	dataBits int
+	stopBits string
+	channel  uint
+	json     bool
+	simulate bool
+	stdout   io.Writer
+}
+
+/*
+Creates the flag set of a command with the shared flags
+*/
+func newFlags(name string, summary string, stdout io.Writer, stderr io.Writer) (*flag.FlagSet, *options) {
+	o := &options{stdout: stdout}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: oem750x %s\n\nFlags:\n", summary)
+		fs.PrintDefaults()
+	}
+
+	port := os.Getenv("OEM750X_PORT")
+	if port == "" {
+		port = "/dev/ttyUSB0"
+	}
+	fs.StringVar(&o.port, "port", port, "serial port of the drive (default from OEM750X_PORT)")
+	fs.IntVar(&o.baud, "baud", 9600, "baud rate")
+	fs.StringVar(&o.parity, "parity", "none", "parity: none, odd, even, mark or space")
+	fs.IntVar(&o.dataBits, "data-bits", 8, "data bits")
+	fs.StringVar(&o.stopBits, "stop-bits", "1", "stop bits: 1, 1.5 or 2")
+	fs.UintVar(&o.channel, "channel", 1, "address of the drive in the daisy chain")
+	fs.BoolVar(&o.json, "json", false, "print the output as JSON")
+	fs.BoolVar(&o.simulate, "simulate", false, "use the in-process simulator instead of a serial port")
+	return fs, o
+}
+
+/*
+Parses the flags of a command, returning errUsage when the
+flags are invalid or the help was requested
+*/
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+/*
+Returns the serial options of the flags
+*/
+func (o *options) serial() (unicommserial.SerialOptions, error) {
+	parities := map[string]unicommserial.Parity{
+		"none":  unicommserial.NoParity,
+		"odd":   unicommserial.OddParity,
+		"even":  unicommserial.EvenParity,
+		"mark":  unicommserial.MarkParity,
+		"space": unicommserial.SpaceParity,
+	}
+	stopBits := map[string]unicommserial.StopBits{
+		"1":   unicommserial.OneStopBit,
+		"1.5": unicommserial.OnePointFiveStopBits,
+		"2":   unicommserial.TwoStopBits,
+	}
+	parity, exists := parities[strings.ToLower(o.parity)]
+	if !exists {
+		return unicommserial.SerialOptions{}, fmt.Errorf("invalid parity %q", o.parity)
+	}
+	stop, exists := stopBits[o.stopBits]
+	if !exists {
+		return unicommserial.SerialOptions{}, fmt.Errorf("invalid stop bits %q", o.stopBits)
+	}
+	return unicommserial.SerialOptions{
+		PortName: o.port,
+		BaudRate: o.baud,
+		Parity:   parity,
+		DataBits: o.dataBits,
+		StopBits: stop,
+	}, nil
+}
+
+/*
+Connects to the drive, returning the function that closes
+the connection
+*/
+func (o *options) connect() (*protocol.OEM750x, func(), error) {
+	var parker *protocol.OEM750x
+	if o.simulate {
+		parker = oem750x.NewWithCommunication(simulator.New(simulator.Options{
+			Addresses: []uint{o.channel},
+		}))
+	} else {
+		serial, err := o.serial()
+		if err != nil {
+			return nil, nil, err
+		}
+		parker = oem750x.New(unicomm.Options{
+			Protocol: unicomm.Serial,
+			Serial:   serial,
+		})
+	}
+	if err := parker.Connect(); err != nil {
+		return nil, nil, fmt.Errorf("connecting to %s: %w", o.port, err)
+	}
+	return parker, func() { parker.Disconnect() }, nil
+}
+
+/*
+Prints the value as indented JSON or with the human
+readable printer, according to the -json flag
+*/
+func (o *options) print(value any, human func(w io.Writer)) error {
+	if o.json {
+		encoder := json.NewEncoder(o.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	human(o.stdout)
+	return nil
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"golang.org/x/term"
+)
+
+var builtins = []struct{ name, summary string }{
+	{"use", "use N selects the channel of the commands without address"},
+	{"status", "reports the status of the selected channel"},
+	{"request", "request CMD sends a command and waits for its response"},
+	{"write", "write CMD sends a command without response"},
+	{"help", "lists the built-in commands and the drive mnemonics"},
+	{"exit", "closes the shell (also quit or Ctrl-D)"},
+}
+
+/*
+Interactive session on top of Request and Write where the
+commands without address go to the selected channel
+*/
+type shell struct {
+	parker  *protocol.OEM750x
+	mutex   sync.Mutex
+	channel uint
+}
+
+/*
+Opens an interactive shell, or runs the commands read from
+the standard input when it is not a terminal
+*/
+func shellCommand(args []string, stdout io.Writer, stderr io.Writer) error {
+	fs, o := newFlags("shell", "shell [-refresh D] [flags]", stdout, stderr)
+	refresh := fs.Duration("refresh", 500*time.Millisecond, "refresh interval of the status line (0 disables it)")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	parker, disconnect, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	s := &shell{parker: parker, channel: o.channel}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return s.script(os.Stdin, stdout)
+	}
+	return s.interactive(os.Stdin, stdout, *refresh)
+}
+
+/*
+Returns the selected channel
+*/
+func (s *shell) selected() uint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.channel
+}
+
+/*
+Reads lines with history and completion until exit or Ctrl-D,
+refreshing the R, RA and PR status line in the prompt
+*/
+func (s *shell) interactive(stdin *os.File, stdout io.Writer, refresh time.Duration) error {
+	fd := int(stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{stdin, stdout}, s.prompt(refresh > 0))
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		t.SetSize(width, height)
+	}
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		line, pos, candidates := complete(line, pos)
+		if len(candidates) > 1 {
+			fmt.Fprintln(t, strings.Join(candidates, "  "))
+		}
+		return line, pos, true
+	}
+	fmt.Fprintln(t, "Type help for the commands, exit or Ctrl-D to quit")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if refresh > 0 {
+		go func() {
+			ticker := time.NewTicker(refresh)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					t.SetPrompt(s.prompt(true))
+					t.Write(nil)
+				}
+			}
+		}()
+	}
+
+	for {
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		quit, err := s.execute(line, t)
+		if err != nil {
+			fmt.Fprintf(t, "error: %v\n", err)
+		}
+		if quit {
+			return nil
+		}
+		t.SetPrompt(s.prompt(refresh > 0))
+	}
+}
+
+/*
+Executes every line of the reader, reporting the errors
+and continuing with the next line
+*/
+func (s *shell) script(input io.Reader, output io.Writer) error {
+	failed := 0
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		quit, err := s.execute(scanner.Text(), output)
+		if err != nil {
+			fmt.Fprintf(output, "error: %v\n", err)
+			failed++
+		}
+		if quit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d command(s) failed", failed)
+	}
+	return nil
+}
+
+/*
+Returns the prompt of the selected channel, with the
+indexer status, limits status and position when the
+status line is enabled. While the indexer is busy the
+position is relative to the start of the move (W3), as
+PR is buffered and only answered when the move ends
+*/
+func (s *shell) prompt(status bool) string {
+	channel := s.selected()
+	if !status {
+		return fmt.Sprintf("[%d]> ", channel)
+	}
+	indexer, err := s.parker.GetIndexerStatus(channel)
+	if err != nil {
+		return fmt.Sprintf("[%d ?]> ", channel)
+	}
+	limits, err := s.parker.ReadLimitStatus(channel)
+	if err != nil {
+		return fmt.Sprintf("[%d %s ?]> ", channel, indexer)
+	}
+	report, read := "PR", s.parker.GetAbsolutePosition
+	if busy(indexer) {
+		report, read = "W3", s.parker.GetRelativePosition
+	}
+	position, err := read(channel)
+	if err != nil {
+		return fmt.Sprintf("[%d %s RA %s ?]> ", channel, indexer, limits)
+	}
+	return fmt.Sprintf("[%d %s RA %s %s %d]> ", channel, indexer, limits, report, position)
+}
+
+/*
+Executes a line of the shell, returning true when the
+shell must be closed. Several drive commands can be given
+in the same line separated by spaces
+*/
+func (s *shell) execute(line string, out io.Writer) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+	switch fields[0] {
+	case "exit", "quit":
+		return true, nil
+	case "help":
+		s.help(out)
+		return false, nil
+	case "status":
+		return false, s.status(out)
+	case "use":
+		if len(fields) != 2 {
+			return false, fmt.Errorf("usage: use N")
+		}
+		return false, s.use(fields[1])
+	case "request", "write":
+		if len(fields) != 2 {
+			return false, fmt.Errorf("usage: %s CMD", fields[0])
+		}
+		command := s.address(strings.ToUpper(fields[1]))
+		if fields[0] == "write" {
+			return false, s.parker.Write(command)
+		}
+		m, _ := splitCommand(command)
+		return false, s.request(command, m, out)
+	}
+
+	for _, field := range fields {
+		command := s.address(strings.ToUpper(field))
+		m, argument := splitCommand(command)
+		if m == nil {
+			return false, fmt.Errorf("unknown command %q, send it with request or write", field)
+		}
+		var err error
+		if m.replies(argument) {
+			err = s.request(command, m, out)
+		} else {
+			err = s.parker.Write(command)
+		}
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", command, err)
+		}
+	}
+	return false, nil
+}
+
+/*
+Prefixes the command with the selected channel when
+it has no address
+*/
+func (s *shell) address(command string) string {
+	if command != "" && command[0] >= '0' && command[0] <= '9' {
+		return command
+	}
+	return fmt.Sprint(s.selected()) + command
+}
+
+/*
+Sends the command and prints the raw response followed
+by its decoded value
+*/
+func (s *shell) request(command string, m *mnemonic, out io.Writer) error {
+	response, err := s.parker.Request(command)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		fmt.Fprintln(out, string(response))
+		return nil
+	}
+	description, err := m.describe(string(response))
+	if err != nil || description == "" {
+		fmt.Fprintln(out, string(response))
+		return nil
+	}
+	fmt.Fprintf(out, "%s  (%s)\n", response, description)
+	return nil
+}
+
+/*
+Selects the channel of the commands without address
+*/
+func (s *shell) use(argument string) error {
+	channel, err := strconv.ParseUint(argument, 10, 32)
+	if err != nil || channel < 1 || channel > 255 {
+		return fmt.Errorf("invalid channel %q", argument)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.channel = uint(channel)
+	return nil
+}
+
+/*
+Prints the status of the selected channel. The buffered
+reports are only read while the indexer is ready
+*/
+func (s *shell) status(out io.Writer) error {
+	channel := s.selected()
+	indexer, err := s.parker.GetIndexerStatus(channel)
+	if err != nil {
+		return err
+	}
+	limits, err := s.parker.ReadLimitStatus(channel)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Indexer:\t%s\n", describeIndexer(indexer))
+	if busy(indexer) {
+		position, err := s.parker.GetRelativePosition(channel)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "Position:\t%d (from start of move)\n", position)
+		fmt.Fprintf(tw, "Limits:\t%s\n", describeLimits(limits))
+		fmt.Fprintf(tw, "Closed loop:\t%s\n", unavailableWhileBusy)
+		return tw.Flush()
+	}
+	closedLoop, err := s.parker.ReadClosedLoopStatus(channel)
+	if err != nil {
+		return err
+	}
+	position, err := s.parker.GetAbsolutePosition(channel)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tw, "Position:\t%d\n", position)
+	fmt.Fprintf(tw, "Limits:\t%s\n", describeLimits(limits))
+	fmt.Fprintf(tw, "Closed loop:\t%s\n", describeClosedLoop(closedLoop))
+	return tw.Flush()
+}
+
+/*
+Prints the built-in commands and the drive mnemonics
+*/
+func (s *shell) help(out io.Writer) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, builtin := range builtins {
+		fmt.Fprintf(tw, "  %s\t%s\n", builtin.name, builtin.summary)
+	}
+	fmt.Fprintln(tw, "\nDrive commands, prefixed with the selected channel when they have no address:")
+	for _, m := range mnemonicList {
+		fmt.Fprintf(tw, "  %s\t%s\n", m.name, m.summary)
+	}
+	tw.Flush()
+}
+
+/*
+Completes the word before the cursor with the built-in
+commands or the drive mnemonics, returning the candidates
+when the completion is ambiguous
+*/
+func complete(line string, pos int) (string, int, []string) {
+	start := strings.LastIndexByte(line[:pos], ' ') + 1
+	word := line[start:pos]
+
+	var candidates []string
+	suffix := ""
+	if start == 0 {
+		for _, builtin := range builtins {
+			if strings.HasPrefix(builtin.name, word) {
+				candidates = append(candidates, builtin.name)
+			}
+		}
+		suffix = " "
+	}
+	digits := len(word) - len(strings.TrimLeft(word, "0123456789"))
+	if len(candidates) == 0 {
+		prefix := strings.ToUpper(word[digits:])
+		for _, m := range mnemonicList {
+			if strings.HasPrefix(m.name, prefix) {
+				candidates = append(candidates, word[:digits]+m.name)
+			}
+		}
+		suffix = ""
+	}
+	if len(candidates) == 0 {
+		return line, pos, nil
+	}
+
+	common := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(candidates) == 1 {
+		common += suffix
+	}
+	if len(common) < len(word) {
+		common = word
+	}
+	return line[:start] + common + line[pos:], start + len(common), candidates
+}
@@ -4,7 +4,11 @@ go 1.23.4
 
 toolchain go1.24.10
 
-require github.com/devicehub-go/unicomm v0.0.0-20251119134514-d1aac7d5f57d
+require (
+	github.com/devicehub-go/unicomm v0.0.0-20251119134514-d1aac7d5f57d
+	golang.org/x/term v0.19.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/creack/goselect v0.1.2 // indirect
@@ -22,3 +22,19 @@ func New(options unicomm.Options) *protocol.OEM750x {
 	}
 	return oem750
 }
+
+/*
+Creates a new instance of Parker Drive OEM750X over an
+already created communication, such as the in-process
+simulator or a custom transport
+
+Paramaters:
+  - communication: communication used to exchange the
+    messages with the device, delimited by CR
+*/
+func NewWithCommunication(communication unicomm.Unicomm) *protocol.OEM750x {
+	oem750 := &protocol.OEM750x{
+		Communication: communication,
+	}
+	return oem750
+}
@@ -0,0 +1,260 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+const (
+	MinVelocity     float64 = 0.01
+	MaxVelocity     float64 = 50.0
+	MinAcceleration float64 = 0.01
+	MaxAcceleration float64 = 999.0
+	MaxDistance     int     = 2147483648
+)
+
+/*
+Mechanical configuration of an axis used to convert engineering
+units (mm, degrees, revolutions) into motor steps
+
+  - StepsPerRev: motor resolution in steps per revolution (MR)
+  - Pitch: units travelled per revolution of the output, such as
+    the lead of a screw in mm or 360 for degrees (default is 1)
+  - GearRatio: motor revolutions per output revolution (default is 1)
+  - EncoderResolution: encoder steps per motor revolution (ER)
+  - EncoderStepMode: distances and positions are in encoder steps (FSB1)
+*/
+type AxisConfig struct {
+	StepsPerRev       uint
+	Pitch             float64
+	GearRatio         float64
+	EncoderResolution uint
+	EncoderStepMode   bool
+}
+
+/*
+Axis of a motor connected to a channel of the drive that
+works in engineering units instead of raw steps and rps
+*/
+type Axis struct {
+	Drive   *OEM750x
+	Channel uint
+	Config  AxisConfig
+}
+
+/*
+Creates a new axis for the channel of the drive
+
+Parameters:
+  - drive: drive the motor is connected to
+  - channel: address of the motor in the daisy chain
+  - config: mechanical configuration of the axis
+*/
+func NewAxis(drive *OEM750x, channel uint, config AxisConfig) (*Axis, error) {
+	if config.StepsPerRev == 0 {
+		return nil, fmt.Errorf("%w: steps per revolution must be greater than zero", ErrInvalidParameter)
+	}
+	if config.Pitch == 0 {
+		config.Pitch = 1
+	}
+	if config.GearRatio == 0 {
+		config.GearRatio = 1
+	}
+	if config.Pitch < 0 || config.GearRatio < 0 {
+		return nil, fmt.Errorf("%w: pitch and gear ratio must be positive", ErrInvalidParameter)
+	}
+	if config.EncoderStepMode && config.EncoderResolution == 0 {
+		return nil, fmt.Errorf("%w: encoder step mode requires the encoder resolution", ErrInvalidParameter)
+	}
+	return &Axis{Drive: drive, Channel: channel, Config: config}, nil
+}
+
+/*
+Returns the units travelled per motor revolution
+*/
+func (a *Axis) UnitsPerRev() float64 {
+	return a.Config.Pitch / a.Config.GearRatio
+}
+
+/*
+Returns the number of steps used by distances and positions
+per unit, that are encoder steps in encoder step mode
+*/
+func (a *Axis) StepsPerUnit() float64 {
+	resolution := a.Config.StepsPerRev
+	if a.Config.EncoderStepMode {
+		resolution = a.Config.EncoderResolution
+	}
+	return float64(resolution) / a.UnitsPerRev()
+}
+
+/*
+Converts a distance in units into the nearest number of steps
+*/
+func (a *Axis) ToSteps(units float64) (int, error) {
+	steps := math.Round(units * a.StepsPerUnit())
+	if steps > float64(MaxDistance) || steps < -float64(MaxDistance) {
+		return 0, fmt.Errorf("distance %g: %w", units, &RangeError{Parameter: "steps", Value: steps, Min: -float64(MaxDistance), Max: float64(MaxDistance)})
+	}
+	return int(steps), nil
+}
+
+/*
+Converts a number of steps into units
+*/
+func (a *Axis) ToUnits(steps int) float64 {
+	return float64(steps) / a.StepsPerUnit()
+}
+
+/*
+Sends the motor resolution of the configuration to the drive
+and, when the encoder resolution is given, the encoder
+resolution (ER) and the step mode (FSB)
+*/
+func (a *Axis) Configure() error {
+	if err := a.Drive.SetResolution(a.Channel, a.Config.StepsPerRev); err != nil {
+		return err
+	}
+	if a.Config.EncoderResolution == 0 {
+		return nil
+	}
+	if err := a.Drive.SetEncoderResolution(a.Channel, a.Config.EncoderResolution); err != nil {
+		return err
+	}
+	mode := MotorSteps
+	if a.Config.EncoderStepMode {
+		mode = EncoderSteps
+	}
+	return a.Drive.SetIndexerMode(a.Channel, mode)
+}
+
+/*
+Returns the maximum velocity in rps supported by the indexer
+for the configured motor resolution
+*/
+func (a *Axis) maxVelocity() float64 {
+	switch resolution := a.Config.StepsPerRev; {
+	case resolution <= 25600:
+		return MaxVelocity
+	case resolution <= 36000:
+		return 40
+	case resolution <= 50800:
+		return 30
+	case resolution <= 278528:
+		return 4.5
+	case resolution <= 425984:
+		return 3
+	case resolution <= 507904:
+		return 2.5
+	case resolution <= 614400:
+		return 2
+	case resolution <= 819200:
+		return 1.5
+	}
+	return 1.25
+}
+
+/*
+Sets the speed of the axis in units per second
+*/
+func (a *Axis) SetSpeed(speed float64) error {
+	rps := speed / a.UnitsPerRev()
+	if limit := a.maxVelocity(); rps < MinVelocity || rps > limit {
+		return fmt.Errorf(
+			"speed %g units/s, between %g and %g units/s: %w",
+			speed, MinVelocity*a.UnitsPerRev(), limit*a.UnitsPerRev(),
+			&RangeError{Parameter: "velocity", Value: rps, Min: MinVelocity, Max: limit},
+		)
+	}
+	return a.Drive.SetTargetVelocity(a.Channel, rps)
+}
+
+/*
+Gets the speed of the axis in units per second
+*/
+func (a *Axis) Speed() (float64, error) {
+	rps, err := a.Drive.GetTargetVelocity(a.Channel)
+	if err != nil {
+		return 0, err
+	}
+	return rps * a.UnitsPerRev(), nil
+}
+
+/*
+Sets the acceleration of the axis in units per second squared
+*/
+func (a *Axis) SetAcceleration(acceleration float64) error {
+	rps2 := acceleration / a.UnitsPerRev()
+	if rps2 < MinAcceleration || rps2 > MaxAcceleration {
+		return fmt.Errorf(
+			"acceleration %g units/s², between %g and %g units/s²: %w",
+			acceleration, MinAcceleration*a.UnitsPerRev(), MaxAcceleration*a.UnitsPerRev(),
+			&RangeError{Parameter: "acceleration", Value: rps2, Min: MinAcceleration, Max: MaxAcceleration},
+		)
+	}
+	return a.Drive.SetTargetAcceleration(a.Channel, rps2)
+}
+
+/*
+Gets the acceleration of the axis in units per second squared
+*/
+func (a *Axis) Acceleration() (float64, error) {
+	rps2, err := a.Drive.GetTargetAcceleration(a.Channel)
+	if err != nil {
+		return 0, err
+	}
+	return rps2 * a.UnitsPerRev(), nil
+}
+
+/*
+Gets the absolute position of the axis in units
+*/
+func (a *Axis) Position() (float64, error) {
+	if err := a.Drive.checkIdle(context.Background(), a.Channel); err != nil {
+		return 0, err
+	}
+	steps, err := a.Drive.GetAbsolutePosition(a.Channel)
+	if err != nil {
+		return 0, err
+	}
+	return a.ToUnits(steps), nil
+}
+
+/*
+Moves the axis to an absolute position in units and blocks
+until the move is finished
+*/
+func (a *Axis) MoveTo(ctx context.Context, position float64) error {
+	steps, err := a.ToSteps(position)
+	if err != nil {
+		return err
+	}
+	return a.Drive.MoveTo(ctx, a.Channel, steps)
+}
+
+/*
+Moves the axis by a distance in units relative to the current
+position and blocks until the move is finished
+*/
+func (a *Axis) MoveBy(ctx context.Context, distance float64) error {
+	steps, err := a.ToSteps(distance)
+	if err != nil {
+		return err
+	}
+	return a.Drive.MoveBy(ctx, a.Channel, steps)
+}
+
+/*
+Sets the absolute position counter to zero
+*/
+func (a *Axis) SetZero() error {
+	return a.Drive.SetZeroPosition(a.Channel)
+}
+
+/*
+Stops the axis
+*/
+func (a *Axis) Stop() error {
+	return a.Drive.Stop(a.Channel)
+}
@@ -0,0 +1,128 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestAxisConversions(t *testing.T) {
+	axis, err := protocol.NewAxis(nil, 1, protocol.AxisConfig{
+		StepsPerRev: 25000,
+		Pitch:       5,
+		GearRatio:   2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if axis.UnitsPerRev() != 2.5 || axis.StepsPerUnit() != 10000 {
+		t.Fatalf("units per rev %g, steps per unit %g", axis.UnitsPerRev(), axis.StepsPerUnit())
+	}
+	if steps, err := axis.ToSteps(-1.23456); err != nil || steps != -12346 {
+		t.Fatalf("steps = %d, %v", steps, err)
+	}
+	if _, err := axis.ToSteps(1e6); err == nil {
+		t.Fatal("expected an error for a distance out of range")
+	}
+	if err := axis.SetSpeed(200); err == nil {
+		t.Fatal("expected an error for 80 rps")
+	}
+	if err := axis.SetAcceleration(0.01); err == nil {
+		t.Fatal("expected an error for 0.004 rps²")
+	}
+
+	encoder, err := protocol.NewAxis(nil, 1, protocol.AxisConfig{
+		StepsPerRev:       25000,
+		Pitch:             360,
+		EncoderResolution: 4000,
+		EncoderStepMode:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if degrees := encoder.ToUnits(1000); degrees != 90 {
+		t.Fatalf("1000 encoder steps = %g degrees", degrees)
+	}
+
+	for _, config := range []protocol.AxisConfig{
+		{Pitch: 5},
+		{StepsPerRev: 25000, Pitch: -5},
+		{StepsPerRev: 25000, Pitch: 5, EncoderStepMode: true},
+	} {
+		if _, err := protocol.NewAxis(nil, 1, config); !errors.Is(err, protocol.ErrInvalidParameter) {
+			t.Fatalf("expected an error for %+v, got %v", config, err)
+		}
+	}
+}
+
+func TestAxisMoves(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := oem750x.NewWithCommunication(sim)
+	parker.PollInterval = 5 * time.Millisecond
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	axis, err := protocol.NewAxis(parker, 1, protocol.AxisConfig{StepsPerRev: 200, Pitch: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := axis.Configure(); err != nil {
+		t.Fatal(err)
+	} else if err := axis.SetSpeed(200); err != nil {
+		t.Fatal(err)
+	} else if err := axis.SetAcceleration(4000); err != nil {
+		t.Fatal(err)
+	}
+	if speed, err := axis.Speed(); err != nil || speed != 200 {
+		t.Fatalf("speed = %g, %v", speed, err)
+	}
+
+	ctx := context.Background()
+	if err := axis.MoveTo(ctx, 25); err != nil {
+		t.Fatal(err)
+	}
+	if steps := sim.Position(1); steps != 1000 {
+		t.Fatalf("position = %d steps", steps)
+	}
+	if err := axis.MoveBy(ctx, -2.5); err != nil {
+		t.Fatal(err)
+	}
+	position, err := axis.Position()
+	if err != nil || math.Abs(position-22.5) > 1e-9 {
+		t.Fatalf("position = %g mm, %v", position, err)
+	}
+}
+
+func TestAxisConfigureEncoder(t *testing.T) {
+	parker := oem750x.NewWithCommunication(simulator.New(simulator.Options{}))
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	axis, err := protocol.NewAxis(parker, 1, protocol.AxisConfig{
+		StepsPerRev:       25000,
+		EncoderResolution: 2000,
+		EncoderStepMode:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := axis.Configure(); err != nil {
+		t.Fatal(err)
+	}
+	if resolution, err := parker.GetEncoderResolution(1); err != nil || resolution != 2000 {
+		t.Fatalf("encoder resolution = %d, %v", resolution, err)
+	}
+	if functions, err := parker.GetEncoderFunctions(1); err != nil || !functions.EncoderSteps {
+		t.Fatalf("encoder functions = %s, %v", functions, err)
+	}
+}
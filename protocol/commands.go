@@ -3,7 +3,6 @@ package protocol
 import (
 	"context"
 	"fmt"
-	"time"
 )
 
 /*
@@ -12,8 +11,21 @@ will move the steps defined by the user when a go
 command is sent
 */
 func (o *OEM750x) SetNormalMode(channel uint) error {
+	return o.SetNormalModeContext(context.Background(), channel)
+}
+
+/*
+Same as SetNormalMode, giving up when the context is done
+*/
+func (o *OEM750x) SetNormalModeContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dMN", channel)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.continuous = false
+	})
+	return nil
 }
 
 /*
@@ -21,185 +33,220 @@ Sets motor to operate in the mode where the it
 will move continuously uintil a stop command is sent
 */
 func (o *OEM750x) SetContinuosMode(channel uint) error {
+	return o.SetContinuosModeContext(context.Background(), channel)
+}
+
+/*
+Same as SetContinuosMode, giving up when the context is done
+*/
+func (o *OEM750x) SetContinuosModeContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dMC", channel)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.continuous = true
+	})
+	return nil
 }
 
 /*
 Sets the positioning mode to absolute
 */
 func (o *OEM750x) SetAbsoluteMode(channel uint) error {
+	return o.SetAbsoluteModeContext(context.Background(), channel)
+}
+
+/*
+Same as SetAbsoluteMode, giving up when the context is done
+*/
+func (o *OEM750x) SetAbsoluteModeContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dMPA", channel)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.absolute = true
+	})
+	return nil
 }
 
 /*
 Sets the positioning mode to incremental
 */
 func (o *OEM750x) SetIncrementalMode(channel uint) error {
+	return o.SetIncrementalModeContext(context.Background(), channel)
+}
+
+/*
+Same as SetIncrementalMode, giving up when the context is done
+*/
+func (o *OEM750x) SetIncrementalModeContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dMPI", channel)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.absolute = false
+	})
+	return nil
 }
 
 /*
 Sets the absolute position counter to zero
 */
 func (o *OEM750x) SetZeroPosition(channel uint) error {
+	return o.SetZeroPositionContext(context.Background(), channel)
+}
+
+/*
+Same as SetZeroPosition, giving up when the context is done
+*/
+func (o *OEM750x) SetZeroPositionContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dPZ", channel)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Moves the motor with the current settings for
-steps or target position movement
+steps or target position movement. The move is
+rejected when it exceeds the software limits
 */
 func (o *OEM750x) Go(channel uint) error {
+	return o.GoContext(context.Background(), channel)
+}
+
+/*
+Same as Go, giving up when the context is done
+*/
+func (o *OEM750x) GoContext(ctx context.Context, channel uint) error {
+	watch, err := o.checkSoftLimits(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if err := o.sendGoContext(ctx, channel); err != nil {
+		return err
+	}
+	if watch != nil {
+		o.startWatch(watch)
+	}
+	return nil
+}
+
+/*
+Sends the go command without checking the software limits
+*/
+func (o *OEM750x) sendGo(channel uint) error {
+	return o.sendGoContext(context.Background(), channel)
+}
+
+func (o *OEM750x) sendGoContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dG", channel)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Moves all available motors with the current settings
-for steps or target position movement
+for steps or target position movement. No motor is
+moved when any of them exceeds its software limits
 */
 func (o *OEM750x) GoAll() error {
-	return o.Write("G")
+	return o.GoAllContext(context.Background())
+}
+
+/*
+Same as GoAll, giving up when the context is done
+*/
+func (o *OEM750x) GoAllContext(ctx context.Context) error {
+	var watches []*limitWatch
+	for _, channel := range o.stateChannels() {
+		watch, err := o.checkSoftLimits(ctx, channel)
+		if err != nil {
+			return err
+		}
+		if watch != nil {
+			watches = append(watches, watch)
+		}
+	}
+	if err := o.WriteContext(ctx, "G"); err != nil {
+		return err
+	}
+	for _, watch := range watches {
+		o.startWatch(watch)
+	}
+	return nil
 }
 
 /*
 Executes the homing procedure with the current settings
 */
 func (o *OEM750x) GoHome(channel uint, direction Direction, speed float64) error {
-	if speed < 0.01 || 50 < speed {
-		return fmt.Errorf("speed must be between 0.01 and 50.00, got %.2f", speed)
+	return o.GoHomeContext(context.Background(), channel, direction, speed)
+}
+
+/*
+Same as GoHome, giving up when the context is done
+*/
+func (o *OEM750x) GoHomeContext(ctx context.Context, channel uint, direction Direction, speed float64) error {
+	if err := checkRange("speed", speed, MinVelocity, MaxVelocity); err != nil {
+		return err
 	}
 	if direction != Forward && direction != Backward {
-		return fmt.Errorf("direction must be '+' (forward) or '-' (backward), got %s", direction)
+		return fmt.Errorf("%w: direction must be '+' (forward) or '-' (backward), got %q", ErrInvalidParameter, direction)
 	}
 	msg := fmt.Sprintf("%dGH%s%.2f", channel, direction, speed)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Executes the homing procedure for all motors
 */
 func (o *OEM750x) GoHomeAll(direction Direction, speed float64) error {
-	if speed < 0.01 || 50 < speed {
-		return fmt.Errorf("speed must be between 0.01 and 50.00, got %.2f", speed)
+	if err := checkRange("speed", speed, MinVelocity, MaxVelocity); err != nil {
+		return err
 	}
 	if direction != Forward && direction != Backward {
-		return fmt.Errorf("direction must be '+' (forward) or '-' (backward), got %s", direction)
+		return fmt.Errorf("%w: direction must be '+' (forward) or '-' (backward), got %q", ErrInvalidParameter, direction)
 	}
 	msg := fmt.Sprintf("GH%s%.2f", direction, speed)
 	return o.Write(msg)
 }
 
 /*
-Executes the homing procedure when just exists CW or CCW limits switches.
-This function blocks until the procedure is finished
+Executes the homing procedure when just exists CW or CCW limits switches,
+moving backward until the limit is reached. This function blocks until
+the procedure is finished and ignores the software limits, since the
+position is unknown until homed. See LimitSwitchHoming to configure it
 */
 func (o *OEM750x) GoHomeHard(ctx context.Context, channel uint, velocity float64) error {
-	var limitReached int = 0
-	var limitSwitchReleased bool = false
-	var polarity Polarity
-
 	response, err := o.GetPolarity(channel)
 	if err != nil {
 		return err
 	}
-	polarity = Polarity(response)
-	oldVelocity, err := o.GetTargetVelocity(channel)
-	if err != nil {
-		return err
-	}
-
-	if err := o.Stop(channel); err != nil {
-		return err
-	} else if err := o.SetTargetVelocity(channel, velocity); err != nil {
-		return err
-	} else if err := o.SetDirection(channel, Backward); err != nil {
-		return err
-	} else if err := o.SetContinuosMode(channel); err != nil {
-		return err
-	} else if err := o.Go(channel); err != nil {
-		return err
-	}
-
-	for limitReached < 5 {
-		select {
-		case <-ctx.Done():
-			o.Stop(channel)
-			return ctx.Err()
-		default:
-			is, err := o.GetIndexerStatus(channel)
-			if err != nil {
-				return err
-			}
-			status, err := o.GetLimitsStatus(channel)
-			if err != nil {
-				return err
-			}
-			if polarity == Normal && status[2] == '1' {
-				limitReached++
-			} else if polarity != Normal && status[3] == '1' {
-				limitReached++
-			} else {
-				limitReached = 0
-				if is == IndexerReady || is == IndexerReadyAttention {
-					if err := o.Go(channel); err != nil {
-						return err
-					}
-				}
-			}
-			time.Sleep(100 * time.Millisecond)
-		}
+	polarity := Polarity(response)
+	limit := CCWLimit
+	if polarity == Normal {
+		limit = CWLimit
 	}
-
-	if err := o.SetTargetVelocity(channel, velocity*0.1); err != nil {
-		return err
-	} else if err := o.SetDirection(channel, Toggle); err != nil {
-		return err
-	} else if err := o.Go(channel); err != nil {
-		return err
-	}
-
-	for !limitSwitchReleased {
-		select {
-		case <-ctx.Done():
-			o.Stop(channel)
-			return ctx.Err()
-		default:
-			status, err := o.GetLimitsStatus(channel)
-			if err != nil {
-				return err
-			}
-			if polarity == Normal {
-				limitSwitchReleased = status[2] == '0'
-			} else {
-				limitSwitchReleased = status[3] == '0'
-			}
-			time.Sleep(10 * time.Millisecond)
-		}
-	}
-
-	if err := o.Stop(channel); err != nil {
-		return err
-	} else if err := o.SetAbsoluteMode(channel); err != nil {
-		return err
-	} else if err := o.SetNormalMode(channel); err != nil {
-		return err
-	} else if err := o.SetTargetVelocity(channel, oldVelocity); err != nil {
-		return err
-	}
-	return o.SetZeroPosition(channel)
+	return o.homeToLimit(ctx, channel, polarity, LimitSwitchHoming{
+		Limit:         limit,
+		HomingOptions: HomingOptions{Velocity: velocity},
+	})
 }
 
 /*
 Stops the motor
 */
 func (o *OEM750x) Stop(channel uint) error {
+	return o.StopContext(context.Background(), channel)
+}
+
+/*
+Same as Stop, giving up when the context is done
+*/
+func (o *OEM750x) StopContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dS", channel)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
@@ -213,8 +260,15 @@ func (o *OEM750x) StopAll() error {
 Ceases the indexer immediately
 */
 func (o *OEM750x) Kill(channel uint) error {
+	return o.KillContext(context.Background(), channel)
+}
+
+/*
+Same as Kill, giving up when the context is done
+*/
+func (o *OEM750x) KillContext(ctx context.Context, channel uint) error {
 	msg := fmt.Sprintf("%dK", channel)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
@@ -222,7 +276,13 @@ Returns all internal settings to their power-up values
 */
 func (o *OEM750x) Reset(channel uint) error {
 	msg := fmt.Sprintf("%dZ", channel)
-	return o.Write(msg)
+	if err := o.Write(msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		*state = channelState{direction: 1, limits: state.limits, aliases: state.aliases}
+	})
+	return nil
 }
 
 /*
@@ -230,6 +290,13 @@ Re-establishes the communication and identify the
 cause of the communication error
 */
 func (o *OEM750x) ResetCommunication(channel uint) (string, error) {
+	return o.ResetCommunicationContext(context.Background(), channel)
+}
+
+/*
+Same as ResetCommunication, giving up when the context is done
+*/
+func (o *OEM750x) ResetCommunicationContext(ctx context.Context, channel uint) (string, error) {
 	msg := fmt.Sprintf("%d%%", channel)
-	return o.RequestString(msg, false)
+	return o.RequestStringContext(ctx, msg, false)
 }
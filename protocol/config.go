@@ -0,0 +1,516 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Motor resolutions in steps per revolution accepted by MR
+that are supported by SetResolution
+*/
+var Resolutions = []uint{
+	200, 400, 1000, 2000, 5000, 10000, 12800, 18000, 20000,
+	21600, 25000, 25400, 25600, 36000, 50000, 50800,
+}
+
+/*
+Declarative setup of a channel. Only the fields that are set
+are sent to the drive, so a profile can describe part of
+the setup. The enumerations are written by name in the files
+(e.g., polarity: inverted, disable_switch: disable_both).
+Outputs and Inputs name the outputs by number and the inputs
+by name (e.g., outputs: {clamp: 1}, inputs: {door: trigger2})
+*/
+type ChannelConfig struct {
+	Channel             uint              `json:"channel" yaml:"channel"`
+	Resolution          *uint             `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	Polarity            *Polarity         `json:"polarity,omitempty" yaml:"polarity,omitempty"`
+	IndexerMode         *IndexerMode      `json:"indexer_mode,omitempty" yaml:"indexer_mode,omitempty"`
+	Positioning         *MovementMode     `json:"positioning,omitempty" yaml:"positioning,omitempty"`
+	Continuous          *bool             `json:"continuous,omitempty" yaml:"continuous,omitempty"`
+	EndLimitsState      *SwitchState      `json:"end_limits_state,omitempty" yaml:"end_limits_state,omitempty"`
+	DisableSwitch       *DisableSwitch    `json:"disable_switch,omitempty" yaml:"disable_switch,omitempty"`
+	HomeSwitchState     *SwitchState      `json:"home_switch_state,omitempty" yaml:"home_switch_state,omitempty"`
+	HomeEdge            *Edge             `json:"home_edge,omitempty" yaml:"home_edge,omitempty"`
+	BackUpHome          *bool             `json:"back_up_home,omitempty" yaml:"back_up_home,omitempty"`
+	HomeIndex           *bool             `json:"home_index,omitempty" yaml:"home_index,omitempty"`
+	StallDetection      *bool             `json:"stall_detection,omitempty" yaml:"stall_detection,omitempty"`
+	StopOnStall         *bool             `json:"stop_on_stall,omitempty" yaml:"stop_on_stall,omitempty"`
+	EncoderResolution   *uint             `json:"encoder_resolution,omitempty" yaml:"encoder_resolution,omitempty"`
+	PositionMaintenance *bool             `json:"position_maintenance,omitempty" yaml:"position_maintenance,omitempty"`
+	DeadBand            *uint             `json:"dead_band,omitempty" yaml:"dead_band,omitempty"`
+	DeadBandWindow      *uint             `json:"dead_band_window,omitempty" yaml:"dead_band_window,omitempty"`
+	CorrectionGain      *uint             `json:"correction_gain,omitempty" yaml:"correction_gain,omitempty"`
+	ErrorChecking       *bool             `json:"error_checking,omitempty" yaml:"error_checking,omitempty"`
+	Shutdown            *bool             `json:"shutdown,omitempty" yaml:"shutdown,omitempty"`
+	Direction           *Direction        `json:"direction,omitempty" yaml:"direction,omitempty"`
+	Velocity            *float64          `json:"velocity,omitempty" yaml:"velocity,omitempty"`
+	Acceleration        *float64          `json:"acceleration,omitempty" yaml:"acceleration,omitempty"`
+	Distance            *int              `json:"distance,omitempty" yaml:"distance,omitempty"`
+	Outputs             map[string]Output `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	Inputs              map[string]Input  `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+/*
+Setup of the channels of a daisy chain as stored in a profile
+*/
+type DriveConfig struct {
+	Channels []ChannelConfig `json:"channels" yaml:"channels"`
+}
+
+/*
+Difference between the configured and the reported value
+of a parameter found by Verify
+*/
+type ConfigDifference struct {
+	Channel   uint
+	Parameter string
+	Expected  string
+	Actual    string
+}
+
+func (d ConfigDifference) String() string {
+	return fmt.Sprintf("channel %d %s: expected %s, got %s", d.Channel, d.Parameter, d.Expected, d.Actual)
+}
+
+/*
+Loads a profile from a YAML or JSON file, selected by the
+extension of the path (.json, .yaml or .yml)
+*/
+func LoadConfig(path string) (DriveConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DriveConfig{}, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseConfigJSON(data)
+	case ".yaml", ".yml":
+		return ParseConfigYAML(data)
+	}
+	return DriveConfig{}, fmt.Errorf("unknown profile format %q, must be .json, .yaml or .yml", filepath.Ext(path))
+}
+
+/*
+Parses and validates a profile in YAML, rejecting unknown fields
+*/
+func ParseConfigYAML(data []byte) (DriveConfig, error) {
+	var config DriveConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil {
+		return DriveConfig{}, fmt.Errorf("invalid profile: %w", err)
+	}
+	return config, config.Validate()
+}
+
+/*
+Parses and validates a profile in JSON, rejecting unknown fields
+*/
+func ParseConfigJSON(data []byte) (DriveConfig, error) {
+	var config DriveConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return DriveConfig{}, fmt.Errorf("invalid profile: %w", err)
+	}
+	return config, config.Validate()
+}
+
+/*
+Encodes the profile in YAML
+*/
+func (c DriveConfig) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+/*
+Encodes the profile in indented JSON
+*/
+func (c DriveConfig) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+/*
+Checks the configuration of every channel and that no
+channel is configured twice
+*/
+func (c DriveConfig) Validate() error {
+	seen := make(map[uint]bool)
+	for _, channel := range c.Channels {
+		if seen[channel.Channel] {
+			return fmt.Errorf("%w: channel %d is configured more than once", ErrInvalidParameter, channel.Channel)
+		}
+		seen[channel.Channel] = true
+		if err := channel.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Checks that every parameter that is set is within the
+range accepted by the drive
+*/
+func (c ChannelConfig) Validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("channel %d: "+format, append([]any{c.Channel}, args...)...)
+	}
+	if c.Channel == 0 {
+		return fmt.Errorf("%w: channel address must be greater than zero", ErrInvalidParameter)
+	}
+	if c.Resolution != nil && !slices.Contains(Resolutions, *c.Resolution) {
+		return invalid("%w: resolution %d", ErrInvalidParameter, *c.Resolution)
+	}
+	if c.EncoderResolution != nil {
+		if err := checkRange("encoder resolution", *c.EncoderResolution, MinEncoderResolution, MaxEncoderResolution); err != nil {
+			return invalid("%w", err)
+		}
+	}
+	if c.DeadBand != nil {
+		if err := checkRange("dead band", *c.DeadBand, 0, MaxDeadBand); err != nil {
+			return invalid("%w", err)
+		}
+	}
+	if c.DeadBandWindow != nil {
+		if err := checkRange("dead band window", *c.DeadBandWindow, 0, MaxDeadBand); err != nil {
+			return invalid("%w", err)
+		}
+	}
+	if c.CorrectionGain != nil {
+		if err := checkRange("correction gain", *c.CorrectionGain, MinCorrectionGain, MaxCorrectionGain); err != nil {
+			return invalid("%w", err)
+		}
+	}
+	if c.Velocity != nil {
+		if err := checkRange("velocity", *c.Velocity, MinVelocity, MaxVelocity); err != nil {
+			return invalid("%w", err)
+		}
+	}
+	if c.Acceleration != nil {
+		if err := checkRange("acceleration", *c.Acceleration, MinAcceleration, MaxAcceleration); err != nil {
+			return invalid("%w", err)
+		}
+	}
+	if c.Distance != nil {
+		if err := checkRange("distance", *c.Distance, -MaxDistance, MaxDistance); err != nil {
+			return invalid("%w", err)
+		}
+	}
+	for name, output := range c.Outputs {
+		if name == "" || (output != Output1 && output != Output2) {
+			return invalid("%w: output %q must be 1 or 2, got %d", ErrInvalidParameter, name, output)
+		}
+	}
+	for name, input := range c.Inputs {
+		if _, exists := inputNames[input]; name == "" || !exists {
+			return invalid("%w: input %q", ErrInvalidParameter, name)
+		}
+	}
+	return nil
+}
+
+/*
+Sends the configuration of each channel in a safe order:
+a shutdown is applied first and the motor is energized last,
+limits are set before the motion parameters and the resolution
+before the velocity. The encoder resolution is set before the
+encoder step mode, and position maintenance after its dead band
+and gain. Every configuration is validated before
+anything is sent. Error checking is changed at the very end
+since it changes the communication with the drive
+*/
+func (o *OEM750x) Apply(ctx context.Context, configs ...ChannelConfig) error {
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, config := range configs {
+		if err := o.apply(ctx, config); err != nil {
+			return fmt.Errorf("channel %d: %w", config.Channel, err)
+		}
+	}
+	return nil
+}
+
+func (o *OEM750x) apply(ctx context.Context, c ChannelConfig) error {
+	channel := c.Channel
+	var steps []func() error
+	add := func(set bool, step func() error) {
+		if set {
+			steps = append(steps, step)
+		}
+	}
+
+	add(c.Shutdown != nil && *c.Shutdown, func() error { return o.SetShutdownContext(ctx, channel, true) })
+	add(c.EndLimitsState != nil, func() error { return o.SetEndLimitsStateContext(ctx, channel, *c.EndLimitsState) })
+	add(c.DisableSwitch != nil, func() error { return o.SetDisableSwitchContext(ctx, channel, *c.DisableSwitch) })
+	add(c.HomeSwitchState != nil, func() error { return o.SetActiveStateHomeSwitchContext(ctx, channel, *c.HomeSwitchState) })
+	add(c.HomeEdge != nil, func() error { return o.SetHomeEdgeContext(ctx, channel, *c.HomeEdge) })
+	add(c.BackUpHome != nil, func() error { return o.SetBackUpHomeContext(ctx, channel, *c.BackUpHome) })
+	add(c.HomeIndex != nil, func() error { return o.SetHomeIndexContext(ctx, channel, *c.HomeIndex) })
+	add(c.Resolution != nil, func() error { return o.SetResolutionContext(ctx, channel, *c.Resolution) })
+	add(c.Polarity != nil, func() error { return o.SetPolarityContext(ctx, channel, *c.Polarity) })
+	add(c.EncoderResolution != nil, func() error { return o.SetEncoderResolutionContext(ctx, channel, *c.EncoderResolution) })
+	add(c.IndexerMode != nil, func() error { return o.SetIndexerModeContext(ctx, channel, *c.IndexerMode) })
+	add(c.DeadBand != nil, func() error { return o.SetDeadBandContext(ctx, channel, *c.DeadBand) })
+	add(c.DeadBandWindow != nil, func() error { return o.SetDeadBandWindowContext(ctx, channel, *c.DeadBandWindow) })
+	add(c.CorrectionGain != nil, func() error { return o.SetCorrectionGainContext(ctx, channel, *c.CorrectionGain) })
+	add(c.PositionMaintenance != nil, func() error { return o.SetPositionMaintenanceContext(ctx, channel, *c.PositionMaintenance) })
+	add(c.StallDetection != nil, func() error { return o.SetStallDetectionContext(ctx, channel, *c.StallDetection) })
+	add(c.StopOnStall != nil, func() error { return o.SetStopOnStallContext(ctx, channel, *c.StopOnStall) })
+	add(c.Positioning != nil, func() error { return o.SetIndexerMovementModeContext(ctx, channel, *c.Positioning) })
+	add(c.Continuous != nil, func() error {
+		if *c.Continuous {
+			return o.SetContinuosModeContext(ctx, channel)
+		}
+		return o.SetNormalModeContext(ctx, channel)
+	})
+	add(c.Velocity != nil, func() error { return o.SetTargetVelocityContext(ctx, channel, *c.Velocity) })
+	add(c.Acceleration != nil, func() error { return o.SetTargetAccelerationContext(ctx, channel, *c.Acceleration) })
+	add(c.Distance != nil, func() error { return o.SetTargetDistanceContext(ctx, channel, *c.Distance) })
+	add(c.Direction != nil, func() error { return o.SetDirectionContext(ctx, channel, *c.Direction) })
+	add(len(c.Outputs) > 0, func() error {
+		for name, output := range c.Outputs {
+			if err := o.SetOutputAlias(channel, name, output); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	add(len(c.Inputs) > 0, func() error {
+		for name, input := range c.Inputs {
+			if err := o.SetInputAlias(channel, name, input); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	add(c.Shutdown != nil && !*c.Shutdown, func() error { return o.SetShutdownContext(ctx, channel, false) })
+	add(c.ErrorChecking != nil, func() error { return o.SetErrorCheckingContext(ctx, channel, *c.ErrorChecking) })
+
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Reads the queryable parameters of the channel (MR, V, A, D,
+CMDDIR and ST) into a configuration
+*/
+func (o *OEM750x) ReadConfig(channel uint) (ChannelConfig, error) {
+	config := ChannelConfig{Channel: channel}
+	resolution, err := o.GetResolution(channel)
+	if err != nil {
+		return config, err
+	}
+	velocity, err := o.GetTargetVelocity(channel)
+	if err != nil {
+		return config, err
+	}
+	acceleration, err := o.GetTargetAcceleration(channel)
+	if err != nil {
+		return config, err
+	}
+	distance, err := o.GetTargetDistance(channel)
+	if err != nil {
+		return config, err
+	}
+	polarity, err := o.GetPolarity(channel)
+	if err != nil {
+		return config, err
+	}
+	shutdown, err := o.GetShutdown(channel)
+	if err != nil {
+		return config, err
+	}
+	config.Resolution = ptr(uint(resolution))
+	config.Velocity = &velocity
+	config.Acceleration = &acceleration
+	config.Distance = &distance
+	config.Polarity = ptr(Polarity(polarity))
+	config.Shutdown = ptr(shutdown == 1)
+	return config, nil
+}
+
+/*
+Reads back every queryable parameter that is set in the
+configurations and returns the differences. The other
+parameters cannot be reported by the drive
+*/
+func (o *OEM750x) Verify(ctx context.Context, configs ...ChannelConfig) ([]ConfigDifference, error) {
+	var differences []ConfigDifference
+	for _, expected := range configs {
+		if err := ctx.Err(); err != nil {
+			return differences, err
+		}
+		actual, err := o.ReadConfig(expected.Channel)
+		if err != nil {
+			return differences, fmt.Errorf("channel %d: %w", expected.Channel, err)
+		}
+		check := func(parameter string, set bool, e string, a string) {
+			if set && e != a {
+				differences = append(differences, ConfigDifference{
+					Channel: expected.Channel, Parameter: parameter, Expected: e, Actual: a,
+				})
+			}
+		}
+		check("resolution", expected.Resolution != nil, formatValue(expected.Resolution), formatValue(actual.Resolution))
+		check("velocity", expected.Velocity != nil, formatValue(expected.Velocity), formatValue(actual.Velocity))
+		check("acceleration", expected.Acceleration != nil, formatValue(expected.Acceleration), formatValue(actual.Acceleration))
+		check("distance", expected.Distance != nil, formatValue(expected.Distance), formatValue(actual.Distance))
+		check("polarity", expected.Polarity != nil, formatValue(expected.Polarity), formatValue(actual.Polarity))
+		check("shutdown", expected.Shutdown != nil, formatValue(expected.Shutdown), formatValue(actual.Shutdown))
+	}
+	return differences, nil
+}
+
+/*
+Formats a configuration value for the comparison, with
+the velocity and acceleration rounded as sent to the drive
+*/
+func formatValue[T any](value *T) string {
+	if value == nil {
+		return "unset"
+	}
+	if f, ok := any(*value).(float64); ok {
+		return strconv.FormatFloat(f, 'f', 2, 64)
+	}
+	return fmt.Sprint(*value)
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+var (
+	polarityNames     = map[Polarity]string{Normal: "normal", Inverted: "inverted"}
+	switchStateNames  = map[SwitchState]string{NormallyClosed: "normally_closed", NormallyOpen: "normally_open"}
+	indexerModeNames  = map[IndexerMode]string{MotorSteps: "motor_steps", EncoderSteps: "encoder_steps"}
+	movementModeNames = map[MovementMode]string{Incremental: "incremental", Absolute: "absolute"}
+	edgeNames         = map[Edge]string{EdgeCW: "cw", EdgeCCW: "ccw"}
+	directionNames    = map[Direction]string{Forward: "forward", Backward: "backward", Toggle: "toggle"}
+	inputNames        = map[Input]string{
+		InputTrigger1: "trigger1", InputTrigger2: "trigger2", InputTrigger3: "trigger3",
+		InputHome: "home", InputFault: "fault", InputCCWLimit: "ccw_limit", InputCWLimit: "cw_limit",
+		InputSequenceSelect1: "sequence_select1", InputSequenceSelect2: "sequence_select2",
+		InputSequenceSelect3: "sequence_select3",
+	}
+	disableSwitchNames = map[DisableSwitch]string{
+		EnableBoth: "enable_both", DisableCW: "disable_cw", DisableCCW: "disable_ccw", DisableBoth: "disable_both",
+	}
+)
+
+/*
+Encodes an enumeration value by its name
+*/
+func marshalName[T comparable](value T, names map[T]string) ([]byte, error) {
+	name, exists := names[value]
+	if !exists {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidParameter, value)
+	}
+	return []byte(name), nil
+}
+
+/*
+Decodes an enumeration value from its name
+*/
+func unmarshalName[T comparable](text []byte, names map[T]string, value *T) error {
+	for candidate, name := range names {
+		if strings.EqualFold(string(text), name) {
+			*value = candidate
+			return nil
+		}
+	}
+	valid := make([]string, 0, len(names))
+	for _, name := range names {
+		valid = append(valid, name)
+	}
+	slices.Sort(valid)
+	return fmt.Errorf("%w: %q, must be one of %s", ErrInvalidParameter, text, strings.Join(valid, ", "))
+}
+
+/*
+The enumerations are encoded by name in the profiles
+*/
+func (p Polarity) MarshalText() ([]byte, error) {
+	return marshalName(p, polarityNames)
+}
+
+func (p *Polarity) UnmarshalText(text []byte) error {
+	return unmarshalName(text, polarityNames, p)
+}
+
+func (s SwitchState) MarshalText() ([]byte, error) {
+	return marshalName(s, switchStateNames)
+}
+
+func (s *SwitchState) UnmarshalText(text []byte) error {
+	return unmarshalName(text, switchStateNames, s)
+}
+
+func (m IndexerMode) MarshalText() ([]byte, error) {
+	return marshalName(m, indexerModeNames)
+}
+
+func (m *IndexerMode) UnmarshalText(text []byte) error {
+	return unmarshalName(text, indexerModeNames, m)
+}
+
+func (m MovementMode) MarshalText() ([]byte, error) {
+	return marshalName(m, movementModeNames)
+}
+
+func (m *MovementMode) UnmarshalText(text []byte) error {
+	return unmarshalName(text, movementModeNames, m)
+}
+
+func (e Edge) MarshalText() ([]byte, error) {
+	return marshalName(e, edgeNames)
+}
+
+func (e *Edge) UnmarshalText(text []byte) error {
+	return unmarshalName(text, edgeNames, e)
+}
+
+func (d DisableSwitch) MarshalText() ([]byte, error) {
+	return marshalName(d, disableSwitchNames)
+}
+
+func (d *DisableSwitch) UnmarshalText(text []byte) error {
+	return unmarshalName(text, disableSwitchNames, d)
+}
+
+func (d Direction) MarshalText() ([]byte, error) {
+	return marshalName(d, directionNames)
+}
+
+func (d *Direction) UnmarshalText(text []byte) error {
+	return unmarshalName(text, directionNames, d)
+}
+
+func (i Input) MarshalText() ([]byte, error) {
+	return marshalName(i, inputNames)
+}
+
+func (i *Input) UnmarshalText(text []byte) error {
+	return unmarshalName(text, inputNames, i)
+}
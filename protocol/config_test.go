@@ -0,0 +1,149 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+const homingProfile = `
+channels:
+  - channel: 3
+    continuous: false
+    disable_switch: enable_both
+    end_limits_state: normally_open
+    home_index: true
+    resolution: 50000
+    polarity: inverted
+    direction: forward
+    velocity: 0.8
+    acceleration: 10
+`
+
+const encoderProfile = `
+channels:
+  - channel: 3
+    encoder_resolution: 4000
+    indexer_mode: encoder_steps
+    dead_band: 10
+    dead_band_window: 20
+    correction_gain: 4
+    position_maintenance: true
+`
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "homing.yaml")
+	if err := os.WriteFile(yamlPath, []byte(homingProfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := protocol.LoadConfig(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel := config.Channels[0]
+	if channel.Channel != 3 || *channel.Resolution != 50000 || *channel.Polarity != protocol.Inverted ||
+		*channel.DisableSwitch != protocol.EnableBoth || *channel.Direction != protocol.Forward || !*channel.HomeIndex ||
+		channel.Shutdown != nil {
+		t.Fatalf("unexpected config %+v", channel)
+	}
+
+	data, err := config.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPath := filepath.Join(dir, "homing.json")
+	if err := os.WriteFile(jsonPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := protocol.LoadConfig(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := decoded.JSON(); string(again) != string(data) {
+		t.Fatalf("json round trip differs:\n%s\n%s", data, again)
+	}
+
+	invalid := []string{
+		"channels:\n  - channel: 1\n    resolutoin: 200\n",
+		"channels:\n  - channel: 1\n    resolution: 300\n",
+		"channels:\n  - channel: 1\n    polarity: sideways\n",
+		"channels:\n  - channel: 1\n    correction_gain: 9\n",
+		"channels:\n  - channel: 1\n  - channel: 1\n",
+	}
+	for _, profile := range invalid {
+		if _, err := protocol.ParseConfigYAML([]byte(profile)); err == nil {
+			t.Fatalf("expected an error for profile %q", profile)
+		}
+	}
+}
+
+func TestApplyAndVerify(t *testing.T) {
+	sim := simulator.New(simulator.Options{Addresses: []uint{3}})
+	parker := newFastAxis(t, sim)
+	ctx := context.Background()
+
+	config, err := protocol.ParseConfigYAML([]byte(homingProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Apply(ctx, config.Channels...); err != nil {
+		t.Fatal(err)
+	}
+	differences, err := parker.Verify(ctx, config.Channels...)
+	if err != nil || len(differences) != 0 {
+		t.Fatalf("differences = %v, %v", differences, err)
+	}
+
+	if err := parker.SetTargetVelocity(3, 2); err != nil {
+		t.Fatal(err)
+	}
+	differences, err = parker.Verify(ctx, config.Channels...)
+	if err != nil || len(differences) != 1 || differences[0].Parameter != "velocity" ||
+		differences[0].Expected != "0.80" || differences[0].Actual != "2.00" {
+		t.Fatalf("differences = %v, %v", differences, err)
+	}
+
+	read, err := parker.ReadConfig(3)
+	if err != nil || *read.Resolution != 50000 || *read.Polarity != protocol.Inverted || *read.Shutdown {
+		t.Fatalf("read config = %+v, %v", read, err)
+	}
+
+	encoder, err := protocol.ParseConfigYAML([]byte(encoderProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Apply(ctx, encoder.Channels...); err != nil {
+		t.Fatal(err)
+	}
+	if resolution, err := parker.GetEncoderResolution(3); err != nil || resolution != 4000 {
+		t.Fatalf("encoder resolution = %d, %v", resolution, err)
+	}
+	if gain, err := parker.GetCorrectionGain(3); err != nil || gain != 4 {
+		t.Fatalf("correction gain = %d, %v", gain, err)
+	}
+	if functions, err := parker.GetEncoderFunctions(3); err != nil || !functions.EncoderSteps || !functions.PositionMaintenance {
+		t.Fatalf("encoder functions = %+v, %v", functions, err)
+	}
+
+	velocity := 80.0
+	bad := protocol.ChannelConfig{Channel: 3, Velocity: &velocity}
+	if err := parker.Apply(ctx, bad); err == nil {
+		t.Fatal("expected a validation error")
+	}
+}
+
+func TestValidateChannels(t *testing.T) {
+	duplicated := protocol.DriveConfig{Channels: []protocol.ChannelConfig{{Channel: 1}, {Channel: 1}}}
+	if err := duplicated.Validate(); !errors.Is(err, protocol.ErrInvalidParameter) {
+		t.Fatalf("expected an error for a channel configured twice, got %v", err)
+	}
+	if err := (protocol.ChannelConfig{}).Validate(); !errors.Is(err, protocol.ErrInvalidParameter) {
+		t.Fatalf("expected an error for the channel address 0, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Highest device address of a daisy chain
+*/
+const MaxAddress uint = 255
+
+/*
+Time given to each address to answer during the discovery
+*/
+const DiscoverTimeout = 200 * time.Millisecond
+
+/*
+Identification of a drive that answered on the daisy chain
+*/
+type DriveInfo struct {
+	Address    uint
+	PartNumber string
+	Revision   string
+}
+
+/*
+Parses the RV response, where the revision level is made
+of the letters after the part number (e.g., 92-016678-01E)
+*/
+func ParseDriveInfo(address uint, response string) (DriveInfo, error) {
+	response = strings.TrimPrefix(response, "*")
+	partNumber := strings.TrimRight(response, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	if partNumber == "" {
+		return DriveInfo{}, &ParseError{Expected: "part number", Response: response}
+	}
+	return DriveInfo{
+		Address:    address,
+		PartNumber: partNumber,
+		Revision:   response[len(partNumber):],
+	}, nil
+}
+
+/*
+Gets the part number and revision level of the drive
+*/
+func (o *OEM750x) GetDriveInfo(channel uint) (DriveInfo, error) {
+	return o.GetDriveInfoContext(context.Background(), channel)
+}
+
+/*
+Same as GetDriveInfo, giving up when the context is done
+*/
+func (o *OEM750x) GetDriveInfoContext(ctx context.Context, channel uint) (DriveInfo, error) {
+	response, err := o.GetPartNumberContext(ctx, channel)
+	if err != nil {
+		return DriveInfo{}, err
+	}
+	return ParseDriveInfo(channel, response)
+}
+
+/*
+Probes the addresses from 1 to maxAddress with RV and returns
+the drives that answered. Each probe waits at most the
+DiscoverTimeout, and the addresses that do not answer
+are skipped
+
+Parameters:
+  - ctx: stops the discovery, returning the drives found
+    so far with the context error
+  - maxAddress: highest address probed, up to MaxAddress
+*/
+func (o *OEM750x) Discover(ctx context.Context, maxAddress uint) ([]DriveInfo, error) {
+	if err := checkRange("max address", maxAddress, 1, MaxAddress); err != nil {
+		return nil, err
+	}
+	var drives []DriveInfo
+	for address := uint(1); address <= maxAddress; address++ {
+		if err := ctx.Err(); err != nil {
+			return drives, err
+		}
+		probe, cancel := context.WithTimeout(ctx, DiscoverTimeout)
+		response, err := o.RequestStringContext(probe, fmt.Sprintf("%dRV", address), false)
+		cancel()
+		if err != nil {
+			continue
+		}
+		if info, err := ParseDriveInfo(address, response); err == nil {
+			drives = append(drives, info)
+		}
+	}
+	return drives, nil
+}
+
+/*
+Assigns consecutive addresses to the drives of the daisy
+chain starting at first (#). The first drive takes the
+address and passes the next one along the chain, so the
+value returned to the host tells how many drives exist.
+The channel settings tracked by this instance are kept
+by address, so they must be set again if the addresses
+of the drives changed
+*/
+func (o *OEM750x) AssignAddresses(first uint) (uint, error) {
+	if err := checkRange("first address", first, 1, MaxAddress); err != nil {
+		return 0, err
+	}
+	message := fmt.Sprintf("#%d", first)
+	response, err := o.exchange(context.Background(), message, func() ([]byte, error) {
+		if err := o.Communication.Write([]byte(message + CR)); err != nil {
+			return nil, err
+		}
+		return o.Communication.ReadUntil(CR)
+	})
+	if err != nil {
+		return 0, err
+	}
+	cleaned := string(cleanResponse(response))
+	next, err := strconv.ParseUint(strings.TrimPrefix(cleaned, "#"), 10, 32)
+	if err != nil || !strings.HasPrefix(cleaned, "#") || uint(next) < first {
+		return 0, &ParseError{Expected: "address assignment", Response: cleaned}
+	}
+	return uint(next) - first, nil
+}
@@ -0,0 +1,45 @@
+package protocol_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestDiscoverAndAssignAddresses(t *testing.T) {
+	sim := simulator.New(simulator.Options{Addresses: []uint{2, 5, 7}})
+	parker := oem750x.NewWithCommunication(sim)
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+	ctx := context.Background()
+
+	drives, err := parker.Discover(ctx, 6)
+	expected := []protocol.DriveInfo{
+		{Address: 2, PartNumber: "92-016678-01", Revision: "E"},
+		{Address: 5, PartNumber: "92-016678-01", Revision: "E"},
+	}
+	if err != nil || !reflect.DeepEqual(drives, expected) {
+		t.Fatalf("discover = %+v, %v", drives, err)
+	}
+
+	count, err := parker.AssignAddresses(1)
+	if err != nil || count != 3 {
+		t.Fatalf("assign = %d, %v", count, err)
+	}
+	drives, err = parker.Discover(ctx, protocol.MaxAddress)
+	if err != nil || len(drives) != 3 || drives[0].Address != 1 || drives[2].Address != 3 {
+		t.Fatalf("discover after assignment = %+v, %v", drives, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := parker.Discover(cancelled, 8); err != context.Canceled {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	MinEncoderResolution uint = 1
+	MaxEncoderResolution uint = 50000
+	MaxDeadBand          uint = 999999999
+	MinCorrectionGain    uint = 1
+	MaxCorrectionGain    uint = 8
+)
+
+/*
+Encoder functions reported by FS, in the order of the
+FSA to FSH commands
+*/
+type EncoderFunctions struct {
+	Absolute            bool
+	EncoderSteps        bool
+	PositionMaintenance bool
+	StopOnStall         bool
+	OutputOnStall       bool
+	MultipleAxisStop    bool
+	OutputInDeadBand    bool
+	StallDetection      bool
+}
+
+/*
+Difference between the commanded position of the motor and
+the position of the encoder, where a positive error means
+the motor is ahead of the encoder
+
+Position is reported by PR in encoder steps when the
+indexer is in encoder step mode and in motor steps
+otherwise, while EncoderPosition is always in encoder steps
+*/
+type FollowingError struct {
+	Mode            IndexerMode
+	Position        int
+	EncoderPosition int
+	MotorSteps      float64
+	EncoderSteps    float64
+}
+
+/*
+Parses the FS response made of 8 digits for the encoder
+functions A to H
+*/
+func ParseEncoderFunctions(response string) (EncoderFunctions, error) {
+	digits, err := decodeDigits(response)
+	if err != nil || len(digits) != 8 {
+		return EncoderFunctions{}, &ParseError{Expected: "encoder functions", Response: response}
+	}
+	return EncoderFunctions{
+		Absolute:            digits[0],
+		EncoderSteps:        digits[1],
+		PositionMaintenance: digits[2],
+		StopOnStall:         digits[3],
+		OutputOnStall:       digits[4],
+		MultipleAxisStop:    digits[5],
+		OutputInDeadBand:    digits[6],
+		StallDetection:      digits[7],
+	}, nil
+}
+
+/*
+Returns the 8-character string in the same format as FS
+*/
+func (e EncoderFunctions) String() string {
+	return formatFlags(
+		e.Absolute, e.EncoderSteps, e.PositionMaintenance, e.StopOnStall,
+		e.OutputOnStall, e.MultipleAxisStop, e.OutputInDeadBand, e.StallDetection,
+	)
+}
+
+/*
+Sets the resolution of the encoder in steps per revolution,
+that is four times the line count of a quadrature encoder
+*/
+func (o *OEM750x) SetEncoderResolution(channel uint, value uint) error {
+	return o.SetEncoderResolutionContext(context.Background(), channel, value)
+}
+
+/*
+Same as SetEncoderResolution, giving up when the context is done
+*/
+func (o *OEM750x) SetEncoderResolutionContext(ctx context.Context, channel uint, value uint) error {
+	if err := checkRange("encoder resolution", value, MinEncoderResolution, MaxEncoderResolution); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%dER%d", channel, value)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Gets the resolution of the encoder in steps per revolution
+*/
+func (o *OEM750x) GetEncoderResolution(channel uint) (int, error) {
+	return o.GetEncoderResolutionContext(context.Background(), channel)
+}
+
+/*
+Same as GetEncoderResolution, giving up when the context is done
+*/
+func (o *OEM750x) GetEncoderResolutionContext(ctx context.Context, channel uint) (int, error) {
+	msg := fmt.Sprintf("%dER", channel)
+	return o.RequestIntContext(ctx, msg)
+}
+
+/*
+Gets the absolute position of the encoder, always in
+encoder steps
+*/
+func (o *OEM750x) GetEncoderPosition(channel uint) (int, error) {
+	return o.GetEncoderPositionContext(context.Background(), channel)
+}
+
+/*
+Same as GetEncoderPosition, giving up when the context is done
+*/
+func (o *OEM750x) GetEncoderPositionContext(ctx context.Context, channel uint) (int, error) {
+	msg := fmt.Sprintf("%dPX", channel)
+	return o.RequestIntContext(ctx, msg)
+}
+
+/*
+Sets position maintenance, that moves the motor back to the
+commanded position when the encoder reports an error bigger
+than the dead band. Requires encoder step mode (FSB1) and
+is disabled by the drive when a stall is detected
+*/
+func (o *OEM750x) SetPositionMaintenance(channel uint, enable bool) error {
+	return o.SetPositionMaintenanceContext(context.Background(), channel, enable)
+}
+
+/*
+Same as SetPositionMaintenance, giving up when the context is done
+*/
+func (o *OEM750x) SetPositionMaintenanceContext(ctx context.Context, channel uint, enable bool) error {
+	var value int = 0
+	if enable {
+		value = 1
+	}
+	msg := fmt.Sprintf("%dFSC%d", channel, value)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Sets the dead band in encoder steps, that is the position
+error tolerated by the position maintenance
+*/
+func (o *OEM750x) SetDeadBand(channel uint, steps uint) error {
+	return o.SetDeadBandContext(context.Background(), channel, steps)
+}
+
+/*
+Same as SetDeadBand, giving up when the context is done
+*/
+func (o *OEM750x) SetDeadBandContext(ctx context.Context, channel uint, steps uint) error {
+	if err := checkRange("dead band", steps, 0, MaxDeadBand); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%dDB%d", channel, steps)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Gets the dead band in encoder steps
+*/
+func (o *OEM750x) GetDeadBand(channel uint) (int, error) {
+	return o.GetDeadBandContext(context.Background(), channel)
+}
+
+/*
+Same as GetDeadBand, giving up when the context is done
+*/
+func (o *OEM750x) GetDeadBandContext(ctx context.Context, channel uint) (int, error) {
+	msg := fmt.Sprintf("%dDB", channel)
+	return o.RequestIntContext(ctx, msg)
+}
+
+/*
+Sets the dead band window in motor steps, that is the
+backlash allowed between the motor and the encoder before
+a stall is detected
+*/
+func (o *OEM750x) SetDeadBandWindow(channel uint, steps uint) error {
+	return o.SetDeadBandWindowContext(context.Background(), channel, steps)
+}
+
+/*
+Same as SetDeadBandWindow, giving up when the context is done
+*/
+func (o *OEM750x) SetDeadBandWindowContext(ctx context.Context, channel uint, steps uint) error {
+	if err := checkRange("dead band window", steps, 0, MaxDeadBand); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%dDW%d", channel, steps)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Gets the dead band window in motor steps
+*/
+func (o *OEM750x) GetDeadBandWindow(channel uint) (int, error) {
+	return o.GetDeadBandWindowContext(context.Background(), channel)
+}
+
+/*
+Same as GetDeadBandWindow, giving up when the context is done
+*/
+func (o *OEM750x) GetDeadBandWindowContext(ctx context.Context, channel uint) (int, error) {
+	msg := fmt.Sprintf("%dDW", channel)
+	return o.RequestIntContext(ctx, msg)
+}
+
+/*
+Sets the correction gain of the position maintenance, where
+each correction move covers gain/8 of the position error
+*/
+func (o *OEM750x) SetCorrectionGain(channel uint, gain uint) error {
+	return o.SetCorrectionGainContext(context.Background(), channel, gain)
+}
+
+/*
+Same as SetCorrectionGain, giving up when the context is done
+*/
+func (o *OEM750x) SetCorrectionGainContext(ctx context.Context, channel uint, gain uint) error {
+	if err := checkRange("correction gain", gain, MinCorrectionGain, MaxCorrectionGain); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%dCG%d", channel, gain)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Gets the correction gain of the position maintenance
+*/
+func (o *OEM750x) GetCorrectionGain(channel uint) (int, error) {
+	return o.GetCorrectionGainContext(context.Background(), channel)
+}
+
+/*
+Same as GetCorrectionGain, giving up when the context is done
+*/
+func (o *OEM750x) GetCorrectionGainContext(ctx context.Context, channel uint) (int, error) {
+	msg := fmt.Sprintf("%dCG", channel)
+	return o.RequestIntContext(ctx, msg)
+}
+
+/*
+Gets the encoder functions reported by FS
+*/
+func (o *OEM750x) GetEncoderFunctions(channel uint) (EncoderFunctions, error) {
+	return o.GetEncoderFunctionsContext(context.Background(), channel)
+}
+
+/*
+Same as GetEncoderFunctions, giving up when the context is done
+*/
+func (o *OEM750x) GetEncoderFunctionsContext(ctx context.Context, channel uint) (EncoderFunctions, error) {
+	msg := fmt.Sprintf("%dFS", channel)
+	response, err := o.RequestStringContext(ctx, msg, false)
+	if err != nil {
+		return EncoderFunctions{}, err
+	}
+	return ParseEncoderFunctions(strings.TrimPrefix(response, "*"))
+}
+
+/*
+Compares the position of the motor (PR) with the position
+of the encoder (PX) and reports the following error both in
+motor steps and in encoder steps, using the motor (MR) and
+encoder (ER) resolutions. PR is only reported when the move
+is complete, so this waits for the current move
+*/
+func (o *OEM750x) CompareMotorVsEncoder(channel uint) (FollowingError, error) {
+	functions, err := o.GetEncoderFunctions(channel)
+	if err != nil {
+		return FollowingError{}, err
+	}
+	motorResolution, err := o.GetResolution(channel)
+	if err != nil {
+		return FollowingError{}, err
+	}
+	encoderResolution, err := o.GetEncoderResolution(channel)
+	if err != nil {
+		return FollowingError{}, err
+	}
+	if motorResolution <= 0 || encoderResolution <= 0 {
+		return FollowingError{}, fmt.Errorf("%w: resolutions MR%d and ER%d", ErrInvalidResponse, motorResolution, encoderResolution)
+	}
+	position, err := o.GetAbsolutePosition(channel)
+	if err != nil {
+		return FollowingError{}, err
+	}
+	encoderPosition, err := o.GetEncoderPosition(channel)
+	if err != nil {
+		return FollowingError{}, err
+	}
+
+	ratio := float64(encoderResolution) / float64(motorResolution)
+	result := FollowingError{
+		Mode:            MotorSteps,
+		Position:        position,
+		EncoderPosition: encoderPosition,
+	}
+	if functions.EncoderSteps {
+		result.Mode = EncoderSteps
+		result.EncoderSteps = float64(position - encoderPosition)
+	} else {
+		result.EncoderSteps = float64(position)*ratio - float64(encoderPosition)
+	}
+	result.MotorSteps = result.EncoderSteps / ratio
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestEncoderSettings(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+
+	if err := parker.SetEncoderResolution(1, 4000); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetDeadBand(1, 10); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetDeadBandWindow(1, 25); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetCorrectionGain(1, 4); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetIndexerMode(1, protocol.EncoderSteps); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetPositionMaintenance(1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	getters := []struct {
+		name     string
+		get      func(channel uint) (int, error)
+		expected int
+	}{
+		{"encoder resolution", parker.GetEncoderResolution, 4000},
+		{"dead band", parker.GetDeadBand, 10},
+		{"dead band window", parker.GetDeadBandWindow, 25},
+		{"correction gain", parker.GetCorrectionGain, 4},
+	}
+	for _, getter := range getters {
+		if value, err := getter.get(1); err != nil || value != getter.expected {
+			t.Fatalf("%s = %d, %v", getter.name, value, err)
+		}
+	}
+	functions, err := parker.GetEncoderFunctions(1)
+	if err != nil || functions.String() != "01100000" {
+		t.Fatalf("encoder functions %s, %v", functions, err)
+	}
+
+	if err := parker.SetCorrectionGain(1, 9); !errors.Is(err, protocol.ErrOutOfRange) {
+		t.Fatalf("correction gain 9 returned %v", err)
+	}
+	if err := parker.SetEncoderResolution(1, 0); !errors.Is(err, protocol.ErrOutOfRange) {
+		t.Fatalf("encoder resolution 0 returned %v", err)
+	}
+}
+
+func TestCompareMotorVsEncoder(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+
+	if err := parker.SetEncoderResolution(1, 4000); err != nil {
+		t.Fatal(err)
+	} else if err := parker.MoveTo(context.Background(), 1, 100); err != nil {
+		t.Fatal(err)
+	}
+	sim.SetEncoderSlip(1, 40)
+
+	result, err := parker.CompareMotorVsEncoder(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := protocol.FollowingError{
+		Mode:            protocol.MotorSteps,
+		Position:        100,
+		EncoderPosition: 1960,
+		MotorSteps:      2,
+		EncoderSteps:    40,
+	}
+	if result != expected {
+		t.Fatalf("following error in motor step mode = %+v", result)
+	}
+
+	if err := parker.SetIndexerMode(1, protocol.EncoderSteps); err != nil {
+		t.Fatal(err)
+	}
+	result, err = parker.CompareMotorVsEncoder(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = protocol.FollowingError{
+		Mode:            protocol.EncoderSteps,
+		Position:        100,
+		EncoderPosition: 60,
+		MotorSteps:      2,
+		EncoderSteps:    40,
+	}
+	if result != expected {
+		t.Fatalf("following error in encoder step mode = %+v", result)
+	}
+}
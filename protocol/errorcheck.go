@@ -0,0 +1,170 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Cause of a communication error reported by % when error
+checking is enabled (SSE1)
+*/
+type CommunicationFault string
+
+const (
+	NoCommunicationFault CommunicationFault = ""
+	UpstreamFault        CommunicationFault = "0"
+	FramingFault         CommunicationFault = "2"
+)
+
+func (f CommunicationFault) String() string {
+	switch f {
+	case NoCommunicationFault:
+		return "no error"
+	case UpstreamFault:
+		return "unit upstream has error"
+	case FramingFault:
+		return "framing error"
+	}
+	return fmt.Sprintf("unknown error (%s)", string(f))
+}
+
+/*
+Error reported when the drive echoes an & for each byte of
+the command, meaning it detected a communication error and
+ignored the command. The fault is read with %, which also
+re-establishes the communication
+*/
+type CommunicationError struct {
+	Channel uint
+	Sent    string
+	Fault   CommunicationFault
+}
+
+func (e *CommunicationError) Error() string {
+	return fmt.Sprintf("drive %d ignored %s after a communication error: %s", e.Channel, e.Sent, e.Fault)
+}
+
+func (e *CommunicationError) Unwrap() error {
+	return ErrCommunication
+}
+
+/*
+Parses the response of %, where * alone means no errors
+and the character after it identifies the fault. Every drive
+of the chain answers with its own *, so the fault of the drive
+that detected the error is returned before the UpstreamFault
+of the drives after it
+*/
+func ParseCommunicationFault(response string) (CommunicationFault, error) {
+	if !strings.HasPrefix(response, "*") {
+		return "", &ParseError{Expected: "communication status", Response: response}
+	}
+	fault := NoCommunicationFault
+	for _, code := range strings.Split(response[1:], "*") {
+		switch CommunicationFault(code) {
+		case NoCommunicationFault:
+		case UpstreamFault:
+			fault = UpstreamFault
+		default:
+			return CommunicationFault(code), nil
+		}
+	}
+	return fault, nil
+}
+
+/*
+Re-establishes the communication of the chain and returns
+the cause of the last communication error (%). The command
+takes no address, so every drive answers it
+*/
+func (o *OEM750x) GetCommunicationFault() (CommunicationFault, error) {
+	return o.GetCommunicationFaultContext(context.Background())
+}
+
+/*
+Same as GetCommunicationFault, giving up when the context is done
+*/
+func (o *OEM750x) GetCommunicationFaultContext(ctx context.Context) (CommunicationFault, error) {
+	response, err := o.RequestStringContext(ctx, "%", false)
+	if err != nil {
+		return "", err
+	}
+	return ParseCommunicationFault(response)
+}
+
+/*
+Returns true if the error checking of the channel was
+enabled (SSE1) through this instance
+*/
+func (o *OEM750x) ErrorChecking(channel uint) bool {
+	return o.snapshotState(channel).errorChecking
+}
+
+/*
+Returns true if the echo of a message may be made of &
+characters, which happens for a channel in error checking
+mode or, for broadcast messages, when any channel is
+*/
+func (o *OEM750x) checksErrors(channel uint) bool {
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	if channel != 0 {
+		state, exists := o.states[channel]
+		return exists && state.errorChecking
+	}
+	for _, state := range o.states {
+		if state.errorChecking {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns true if the echo is made only of the & characters
+sent by a drive that ignores commands
+*/
+func ignoredEcho(echo []byte) bool {
+	return len(echo) > 0 && strings.Trim(string(echo), "&") == ""
+}
+
+/*
+Splits the device address from the message, returning
+zero for broadcast messages
+*/
+func messageAddress(message string) uint {
+	digits := len(message) - len(strings.TrimLeft(message, "0123456789"))
+	address, _ := strconv.ParseUint(message[:digits], 10, 32)
+	return uint(address)
+}
+
+/*
+Builds the error of an ignored command, reading the fault
+with %, which re-establishes the communication of the chain.
+The communication must be locked by the caller
+*/
+func (o *OEM750x) communicationError(message string) error {
+	commErr := &CommunicationError{Channel: messageAddress(message), Sent: message}
+	reset := "%"
+	if err := o.Communication.Write([]byte(reset + CR)); err != nil {
+		return errors.Join(commErr, err)
+	}
+	echo, err := o.Communication.ReadUntil(CR)
+	if err != nil || string(echo) != reset+CR {
+		return errors.Join(commErr, &EchoMismatchError{Sent: reset, Received: string(echo)})
+	}
+	response, err := o.Communication.ReadUntil(CR)
+	if err != nil {
+		return errors.Join(commErr, err)
+	}
+	commErr.Fault, err = ParseCommunicationFault(string(cleanResponse(response)))
+	if err != nil {
+		return errors.Join(commErr, err)
+	}
+	return commErr
+}
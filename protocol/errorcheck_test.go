@@ -0,0 +1,84 @@
+package protocol_test
+
+import (
+	"errors"
+	"testing"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestErrorCheckingMode(t *testing.T) {
+	sim := simulator.New(simulator.Options{Addresses: []uint{1, 2}})
+	parker := oem750x.NewWithCommunication(sim)
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	sim.InjectFramingError(1)
+	if err := parker.SetTargetVelocity(1, 2); err != nil {
+		t.Fatalf("framing errors are not detected without SSE1: %v", err)
+	}
+
+	if err := parker.SetErrorChecking(1, true); err != nil || !parker.ErrorChecking(1) || parker.ErrorChecking(2) {
+		t.Fatalf("error checking = %v, %v, %v", parker.ErrorChecking(1), parker.ErrorChecking(2), err)
+	}
+	sim.InjectFramingError(1)
+	var commErr *protocol.CommunicationError
+	err := parker.SetTargetVelocity(1, 3)
+	if !errors.Is(err, protocol.ErrCommunication) || !errors.As(err, &commErr) ||
+		commErr.Channel != 1 || commErr.Sent != "1V3.00" || commErr.Fault != protocol.FramingFault {
+		t.Fatalf("expected a framing error, got %v", err)
+	}
+	if velocity, err := parker.GetTargetVelocity(1); err != nil || velocity != 2 {
+		t.Fatalf("the ignored command must not change the velocity: %v, %v", velocity, err)
+	}
+	if fault, err := parker.GetCommunicationFault(); err != nil || fault != protocol.NoCommunicationFault {
+		t.Fatalf("fault after reset = %q, %v", fault, err)
+	}
+	sim.InjectFramingError(1)
+	if fault, err := parker.GetCommunicationFault(); err != nil || fault != protocol.FramingFault {
+		t.Fatalf("fault of the chain = %q, %v", fault, err)
+	}
+
+	parker.Retry = protocol.RetryPolicy{Retries: 1}
+	sim.InjectFramingError(1)
+	if err := parker.Go(1); err != nil {
+		t.Fatalf("ignored commands are retried, got %v", err)
+	}
+	if !sim.IsMoving(1) {
+		t.Fatal("expected the retried go to start the move")
+	}
+	parker.Kill(1)
+
+	parker.Retry = protocol.RetryPolicy{}
+	if err := parker.Write("2SSE1"); err != nil {
+		t.Fatal(err)
+	}
+	sim.InjectFramingError(2)
+	if _, err := parker.GetIndexerStatus(2); !errors.As(err, &commErr) || commErr.Fault != protocol.FramingFault {
+		t.Fatalf("expected the untracked mode to be detected, got %v", err)
+	}
+	if status, err := parker.GetIndexerStatus(2); err != nil || status != protocol.IndexerReady {
+		t.Fatalf("status after recovery = %q, %v", status, err)
+	}
+}
+
+func TestParseCommunicationFault(t *testing.T) {
+	for response, expected := range map[string]protocol.CommunicationFault{
+		"*":      protocol.NoCommunicationFault,
+		"***":    protocol.NoCommunicationFault,
+		"*0":     protocol.UpstreamFault,
+		"**2*0":  protocol.FramingFault,
+		"*0*2*0": protocol.FramingFault,
+	} {
+		if fault, err := protocol.ParseCommunicationFault(response); err != nil || fault != expected {
+			t.Fatalf("fault of %q = %q, %v", response, fault, err)
+		}
+	}
+	if _, err := protocol.ParseCommunicationFault("2"); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
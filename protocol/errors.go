@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+Sentinel errors of the driver, matched with errors.Is. The
+structured errors (EchoMismatchError, ParseError, RangeError,
+MoveError, SoftLimitError and CommunicationError) unwrap to
+one of them and carry the details, read with errors.As
+*/
+var (
+	ErrNotConnected     = errors.New("device not connected")
+	ErrTimeout          = errors.New("timeout waiting for the device")
+	ErrEchoMismatch     = errors.New("echo differs from the command sent")
+	ErrInvalidResponse  = errors.New("invalid response")
+	ErrOutOfRange       = errors.New("parameter out of range")
+	ErrInvalidParameter = errors.New("invalid parameter")
+	ErrAttention        = errors.New("indexer requires attention")
+	ErrLimitReached     = errors.New("end-of-travel limit reached")
+	ErrPositionMismatch = errors.New("final position differs from target")
+	ErrSoftLimit        = errors.New("move exceeds software travel limits")
+	ErrCommunication    = errors.New("communication error")
+	ErrNotJogging       = errors.New("channel is not jogging")
+	ErrHomingFailed     = errors.New("homing did not find the reference")
+	ErrNotStarted       = errors.New("move did not start")
+	ErrBusy             = errors.New("indexer is busy")
+)
+
+/*
+Error reported when the drive echoes something other than
+the message sent, which usually means the stream is out of
+sync or the command was corrupted on the line
+*/
+type EchoMismatchError struct {
+	Sent     string
+	Received string
+}
+
+func (e *EchoMismatchError) Error() string {
+	return fmt.Sprintf("unexpected response %q to %s", e.Received, e.Sent)
+}
+
+func (e *EchoMismatchError) Unwrap() error {
+	return ErrEchoMismatch
+}
+
+/*
+Error reported when a response of the drive cannot be
+parsed as the expected value
+*/
+type ParseError struct {
+	Expected string
+	Response string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid %s response: %s", e.Expected, e.Response)
+}
+
+func (e *ParseError) Unwrap() error {
+	return ErrInvalidResponse
+}
+
+/*
+Error reported when a parameter is outside of the range
+accepted by the drive, before any command is sent
+*/
+type RangeError struct {
+	Parameter string
+	Value     float64
+	Min       float64
+	Max       float64
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("%s %g out of range, must be between %g and %g", e.Parameter, e.Value, e.Min, e.Max)
+}
+
+func (e *RangeError) Unwrap() error {
+	return ErrOutOfRange
+}
+
+/*
+Returns a RangeError when the value is outside of [min, max]
+*/
+func checkRange[T int | uint | float64](parameter string, value T, min T, max T) error {
+	if value < min || value > max {
+		return &RangeError{Parameter: parameter, Value: float64(value), Min: float64(min), Max: float64(max)}
+	}
+	return nil
+}
+
+/*
+Classifies an error of the communication, whose timeouts
+are only reported as text (e.g., "read until timeout")
+*/
+func transportError(err error) error {
+	if err == nil || errors.Is(err, ErrTimeout) || !strings.Contains(err.Error(), "timeout") {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrTimeout, err)
+}
@@ -0,0 +1,51 @@
+package protocol_test
+
+import (
+	"errors"
+	"testing"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	sim := simulator.New(simulator.Options{Addresses: []uint{1}})
+	parker := oem750x.NewWithCommunication(sim)
+	if err := parker.Write("1V2"); !errors.Is(err, protocol.ErrNotConnected) {
+		t.Fatalf("expected not connected, got %v", err)
+	}
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	var rangeErr *protocol.RangeError
+	err := parker.SetTargetAcceleration(1, 1000)
+	if !errors.Is(err, protocol.ErrOutOfRange) || !errors.As(err, &rangeErr) ||
+		rangeErr.Parameter != "acceleration" || rangeErr.Max != protocol.MaxAcceleration {
+		t.Fatalf("expected an acceleration range error, got %v", err)
+	}
+	if err := parker.SetTargetVelocity(1, 0.005); !errors.As(err, &rangeErr) || rangeErr.Min != protocol.MinVelocity {
+		t.Fatalf("expected a velocity range error, got %v", err)
+	}
+	if err := parker.SetDirection(1, "x"); !errors.Is(err, protocol.ErrInvalidParameter) {
+		t.Fatalf("expected an invalid direction, got %v", err)
+	}
+
+	var parseErr *protocol.ParseError
+	if _, err := protocol.ParseLimitStatus("Z"); !errors.Is(err, protocol.ErrInvalidResponse) ||
+		!errors.As(err, &parseErr) || parseErr.Response != "Z" {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+	if _, err := parker.RequestInt("1PR"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parker.RequestInt("1R"); !errors.As(err, &parseErr) || parseErr.Expected != "integer" {
+		t.Fatalf("expected an integer parse error, got %v", err)
+	}
+
+	if _, err := parker.Request("9R"); !errors.Is(err, protocol.ErrTimeout) {
+		t.Fatalf("expected a timeout for a missing drive, got %v", err)
+	}
+}
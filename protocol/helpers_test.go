@@ -0,0 +1,67 @@
+package protocol_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+	"github.com/devicehub-go/unicomm"
+)
+
+/*
+Returns a connected instance on the simulator with channel 1
+set to a fast profile (MR200, V50 and A999) and a short poll
+interval
+*/
+func newFastAxis(t *testing.T, sim *simulator.Simulator) *protocol.OEM750x {
+	parker := oem750x.NewWithCommunication(sim)
+	parker.PollInterval = 5 * time.Millisecond
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { parker.Disconnect() })
+
+	if err := parker.SetResolution(1, 200); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetTargetVelocity(1, 50); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetTargetAcceleration(1, 999); err != nil {
+		t.Fatal(err)
+	}
+	return parker
+}
+
+/*
+Transport that fails the writes of the given command before
+they reach the drive
+*/
+type failingTransport struct {
+	unicomm.Unicomm
+	mutex sync.Mutex
+	fail  map[string]bool
+}
+
+func (f *failingTransport) Write(message []byte) error {
+	f.mutex.Lock()
+	failed := f.fail[strings.TrimSuffix(string(message), protocol.CR)]
+	f.mutex.Unlock()
+	if failed {
+		return errors.New("write failed")
+	}
+	return f.Unicomm.Write(message)
+}
+
+func (f *failingTransport) failing(commands ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.fail = make(map[string]bool)
+	for _, command := range commands {
+		f.fail[command] = true
+	}
+}
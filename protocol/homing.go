@@ -0,0 +1,456 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+Default values of the homing options
+*/
+const (
+	DefaultHomingVelocity        float64       = 1
+	DefaultHomingDebounce        int           = 5
+	DefaultHomingPollInterval    time.Duration = 100 * time.Millisecond
+	DefaultHomingReleaseInterval time.Duration = 10 * time.Millisecond
+	DefaultHomingBackoffRatio    float64       = 0.1
+)
+
+/*
+Procedure that finds the reference of a channel and sets
+its absolute position to zero. Custom strategies can be
+run with Home as the built-in ones
+*/
+type HomingStrategy interface {
+	Home(ctx context.Context, o *OEM750x, channel uint) error
+}
+
+/*
+End-of-travel limit switch used as the home reference
+*/
+type LimitSwitch uint
+
+const (
+	CWLimit  LimitSwitch = 0
+	CCWLimit LimitSwitch = 1
+)
+
+/*
+Options shared by the homing strategies, where the zero
+values select the defaults. Not every strategy uses all
+of them
+
+Fields:
+  - Velocity: search velocity in rps
+  - Debounce: consecutive samples that must see the input
+    active before the reference is accepted
+  - PollInterval: interval between samples while searching
+  - ReleaseInterval: interval between samples while backing
+    off until the input is released
+  - BackoffVelocity: velocity in rps when leaving the reference,
+    by default a tenth of the search velocity
+  - BackoffDistance: steps moved away from the reference, or
+    zero to move until the input is released
+  - Offset: steps from the reference to the home position,
+    where the absolute position is set to zero
+  - Timeout: maximum duration of the procedure, or zero to
+    only stop when the context is done
+*/
+type HomingOptions struct {
+	Velocity        float64
+	Debounce        int
+	PollInterval    time.Duration
+	ReleaseInterval time.Duration
+	BackoffVelocity float64
+	BackoffDistance int
+	Offset          int
+	Timeout         time.Duration
+}
+
+func (h HomingOptions) velocity() float64 {
+	if h.Velocity <= 0 {
+		return DefaultHomingVelocity
+	}
+	return h.Velocity
+}
+
+func (h HomingOptions) debounce() int {
+	if h.Debounce <= 0 {
+		return DefaultHomingDebounce
+	}
+	return h.Debounce
+}
+
+func (h HomingOptions) pollInterval() time.Duration {
+	if h.PollInterval <= 0 {
+		return DefaultHomingPollInterval
+	}
+	return h.PollInterval
+}
+
+func (h HomingOptions) releaseInterval() time.Duration {
+	if h.ReleaseInterval <= 0 {
+		return DefaultHomingReleaseInterval
+	}
+	return h.ReleaseInterval
+}
+
+func (h HomingOptions) backoffVelocity() float64 {
+	if h.BackoffVelocity <= 0 {
+		return h.velocity() * DefaultHomingBackoffRatio
+	}
+	return h.BackoffVelocity
+}
+
+/*
+Bounds the context by the timeout of the procedure
+*/
+func (h HomingOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, h.Timeout)
+}
+
+/*
+Homes the channel with the given strategy
+*/
+func (o *OEM750x) Home(ctx context.Context, channel uint, strategy HomingStrategy) error {
+	return strategy.Home(ctx, o, channel)
+}
+
+/*
+Homes with the go home command (GH) of the drive, that moves
+in the direction until the home switch is found and stops
+on the edge set by SetHomeEdge. Uses Velocity, PollInterval,
+Offset and Timeout
+*/
+type HomeSwitchHoming struct {
+	Direction Direction
+	HomingOptions
+}
+
+func (h HomeSwitchHoming) Home(ctx context.Context, o *OEM750x, channel uint) error {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+
+	oldVelocity, err := o.GetTargetVelocity(channel)
+	if err != nil {
+		return err
+	}
+	if err := o.GoHome(channel, h.Direction, h.velocity()); err != nil {
+		return err
+	}
+	if err := o.waitForMove(ctx, channel, h.pollInterval()); err != nil {
+		return err
+	}
+	status, err := o.ReadClosedLoopStatus(channel)
+	if err != nil {
+		return err
+	}
+	if status.HomingFailed {
+		return &MoveError{Channel: channel, Status: IndexerReady, Err: ErrHomingFailed}
+	}
+	return o.finishHoming(ctx, channel, oldVelocity, h.HomingOptions)
+}
+
+/*
+Homes with the go home command on the encoder Z channel
+inside the region of the home switch (OSB1 and OSD1). The
+drive must be in encoder step mode (FSB1). Uses the same
+options as HomeSwitchHoming
+*/
+type EncoderIndexHoming struct {
+	Direction Direction
+	HomingOptions
+}
+
+func (h EncoderIndexHoming) Home(ctx context.Context, o *OEM750x, channel uint) error {
+	if err := o.SetBackUpHome(channel, true); err != nil {
+		return err
+	} else if err := o.SetHomeIndex(channel, true); err != nil {
+		return err
+	}
+	return HomeSwitchHoming(h).Home(ctx, o, channel)
+}
+
+/*
+Homes on an end-of-travel limit switch, for axes without a
+home switch. The motor moves continuously towards the limit
+until it is seen active for Debounce samples, then backs
+off the limit and the position is set to zero. The direction
+towards each limit follows the direction polarity. Software
+limits are ignored, since the position is unknown until
+homed. Uses every option
+*/
+type LimitSwitchHoming struct {
+	Limit LimitSwitch
+	HomingOptions
+}
+
+func (h LimitSwitchHoming) Home(ctx context.Context, o *OEM750x, channel uint) error {
+	response, err := o.GetPolarity(channel)
+	if err != nil {
+		return err
+	}
+	return o.homeToLimit(ctx, channel, Polarity(response), h)
+}
+
+/*
+Searches the limit, backs off and finishes the homing
+*/
+func (o *OEM750x) homeToLimit(ctx context.Context, channel uint, polarity Polarity, h LimitSwitchHoming) error {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+
+	direction := Backward
+	if (h.Limit == CWLimit) != (polarity == Normal) {
+		direction = Forward
+	}
+	active := func(status LimitStatus) bool {
+		if h.Limit == CWLimit {
+			return status.CWActive
+		}
+		return status.CCWActive
+	}
+	oldVelocity, err := o.GetTargetVelocity(channel)
+	if err != nil {
+		return err
+	}
+
+	if err := o.Stop(channel); err != nil {
+		return err
+	} else if err := o.SetTargetVelocity(channel, h.velocity()); err != nil {
+		return err
+	} else if err := o.SetDirection(channel, direction); err != nil {
+		return err
+	} else if err := o.SetContinuosMode(channel); err != nil {
+		return err
+	} else if err := o.sendGo(channel); err != nil {
+		return err
+	}
+
+	for samples := 0; samples < h.debounce(); {
+		select {
+		case <-ctx.Done():
+			o.Stop(channel)
+			return ctx.Err()
+		default:
+			is, err := o.GetIndexerStatus(channel)
+			if err != nil {
+				return err
+			}
+			status, err := o.ReadLimitStatus(channel)
+			if err != nil {
+				return err
+			}
+			if active(status) {
+				samples++
+			} else {
+				samples = 0
+				if is == IndexerReady || is == IndexerReadyAttention {
+					if err := o.sendGo(channel); err != nil {
+						return err
+					}
+				}
+			}
+			time.Sleep(h.pollInterval())
+		}
+	}
+
+	if h.BackoffDistance > 0 {
+		if err := o.backOff(ctx, channel, direction, h.HomingOptions); err != nil {
+			return err
+		}
+		return o.finishHoming(ctx, channel, oldVelocity, h.HomingOptions)
+	}
+
+	if err := o.SetTargetVelocity(channel, h.backoffVelocity()); err != nil {
+		return err
+	} else if err := o.SetDirection(channel, Toggle); err != nil {
+		return err
+	} else if err := o.sendGo(channel); err != nil {
+		return err
+	}
+	for released := false; !released; {
+		select {
+		case <-ctx.Done():
+			o.Stop(channel)
+			return ctx.Err()
+		default:
+			status, err := o.ReadLimitStatus(channel)
+			if err != nil {
+				return err
+			}
+			released = !active(status)
+			time.Sleep(h.releaseInterval())
+		}
+	}
+	if err := o.Stop(channel); err != nil {
+		return err
+	}
+	return o.finishHoming(ctx, channel, oldVelocity, h.HomingOptions)
+}
+
+/*
+Homes against a mechanical hard stop, for axes without
+switches. Stall detection (FSH1) and stop on stall (FSD1)
+are enabled, and left enabled, and the motor moves
+continuously in the direction until the stall stops it. RC
+is buffered, so the indexer status (R) is polled during the
+move and RC is read once the motor is ready to check that
+it stopped on a stall. The drive must be in encoder step
+mode (FSB1). Uses every option except Debounce and
+ReleaseInterval, and backs off only when BackoffDistance
+is set
+*/
+type HardStopHoming struct {
+	Direction Direction
+	HomingOptions
+}
+
+func (h HardStopHoming) Home(ctx context.Context, o *OEM750x, channel uint) error {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+
+	if h.Direction != Forward && h.Direction != Backward {
+		return fmt.Errorf("%w: direction must be '+' (forward) or '-' (backward), got %q", ErrInvalidParameter, h.Direction)
+	}
+	oldVelocity, err := o.GetTargetVelocity(channel)
+	if err != nil {
+		return err
+	}
+	if err := o.SetStallDetection(channel, true); err != nil {
+		return err
+	} else if err := o.SetStopOnStall(channel, true); err != nil {
+		return err
+	} else if err := o.Stop(channel); err != nil {
+		return err
+	} else if err := o.SetTargetVelocity(channel, h.velocity()); err != nil {
+		return err
+	} else if err := o.SetDirection(channel, h.Direction); err != nil {
+		return err
+	} else if err := o.SetContinuosMode(channel); err != nil {
+		return err
+	} else if err := o.sendGo(channel); err != nil {
+		return err
+	}
+
+	moveErr := o.waitForMove(ctx, channel, h.pollInterval())
+	if moveErr != nil && !errors.Is(moveErr, ErrAttention) && !errors.Is(moveErr, ErrLimitReached) {
+		return moveErr
+	}
+	status, err := o.ReadClosedLoopStatus(channel)
+	if err != nil {
+		return err
+	}
+	if !status.Stall {
+		if moveErr != nil {
+			return moveErr
+		}
+		return &MoveError{Channel: channel, Status: IndexerReady, Err: ErrHomingFailed}
+	}
+	if h.BackoffDistance > 0 {
+		if err := o.backOff(ctx, channel, h.Direction, h.HomingOptions); err != nil {
+			return err
+		}
+	}
+	return o.finishHoming(ctx, channel, oldVelocity, h.HomingOptions)
+}
+
+/*
+Sets the current position as home, after moving by the
+Offset when it is set. As the other strategies, it leaves
+the motor in normal and absolute mode. Uses Velocity,
+Offset and Timeout
+*/
+type CurrentPositionHoming struct {
+	HomingOptions
+}
+
+func (h CurrentPositionHoming) Home(ctx context.Context, o *OEM750x, channel uint) error {
+	ctx, cancel := h.context(ctx)
+	defer cancel()
+
+	var oldVelocity float64
+	if h.Offset != 0 {
+		velocity, err := o.GetTargetVelocity(channel)
+		if err != nil {
+			return err
+		}
+		oldVelocity = velocity
+	}
+	return o.finishHoming(ctx, channel, oldVelocity, h.HomingOptions)
+}
+
+/*
+Moves BackoffDistance steps away from the reference found
+moving in the direction, at the back-off velocity
+*/
+func (o *OEM750x) backOff(ctx context.Context, channel uint, direction Direction, h HomingOptions) error {
+	distance := h.BackoffDistance
+	if direction == Forward {
+		distance = -distance
+	}
+	return o.homingMove(ctx, channel, distance, h.backoffVelocity(), h.pollInterval())
+}
+
+/*
+Moves a number of steps in normal and incremental mode
+without checking the software limits
+*/
+func (o *OEM750x) homingMove(ctx context.Context, channel uint, steps int, velocity float64, interval time.Duration) error {
+	if err := o.settle(ctx, channel, interval); err != nil {
+		return err
+	}
+	if err := o.SetNormalMode(channel); err != nil {
+		return err
+	} else if err := o.SetIncrementalMode(channel); err != nil {
+		return err
+	} else if err := o.SetTargetVelocity(channel, velocity); err != nil {
+		return err
+	} else if err := o.SetTargetDistance(channel, steps); err != nil {
+		return err
+	} else if err := o.sendGo(channel); err != nil {
+		return err
+	}
+	return o.waitForMove(ctx, channel, interval)
+}
+
+/*
+Waits for the motor to stop at the reference, where the
+attention flag raised by a limit or a stall is expected
+*/
+func (o *OEM750x) settle(ctx context.Context, channel uint, interval time.Duration) error {
+	err := o.waitForMove(ctx, channel, interval)
+	if errors.Is(err, ErrAttention) || errors.Is(err, ErrLimitReached) {
+		return nil
+	}
+	return err
+}
+
+/*
+Moves to the home position by the offset, leaves the motor
+in normal and absolute mode with its previous velocity,
+when known, and sets the absolute position to zero
+*/
+func (o *OEM750x) finishHoming(ctx context.Context, channel uint, velocity float64, h HomingOptions) error {
+	if h.Offset != 0 {
+		if err := o.homingMove(ctx, channel, h.Offset, h.velocity(), h.pollInterval()); err != nil {
+			return err
+		}
+	}
+	if err := o.SetAbsoluteMode(channel); err != nil {
+		return err
+	} else if err := o.SetNormalMode(channel); err != nil {
+		return err
+	}
+	if velocity > 0 {
+		if err := o.SetTargetVelocity(channel, velocity); err != nil {
+			return err
+		}
+	}
+	return o.SetZeroPosition(channel)
+}
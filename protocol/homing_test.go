@@ -0,0 +1,162 @@
+package protocol_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+/*
+Simulator that keeps the position where the counter of
+drive 1 is set to zero
+*/
+type zeroSpy struct {
+	*simulator.Simulator
+	zeroedAt *int
+}
+
+func (z *zeroSpy) Write(message []byte) error {
+	if string(message) == "1PZ\r" {
+		position := z.Position(1)
+		z.zeroedAt = &position
+	}
+	return z.Simulator.Write(message)
+}
+
+func TestHomingStrategies(t *testing.T) {
+	options := protocol.HomingOptions{
+		Velocity:        20,
+		Debounce:        2,
+		PollInterval:    5 * time.Millisecond,
+		ReleaseInterval: time.Millisecond,
+		Timeout:         5 * time.Second,
+	}
+	withBackoff := options
+	withBackoff.BackoffDistance = 50
+	withBackoff.Offset = -100
+	fromStop := options
+	fromStop.BackoffDistance = 50
+	fromStop.Offset = 100
+	withOffset := options
+	withOffset.Offset = 300
+
+	cases := []struct {
+		name     string
+		setup    func(sim *simulator.Simulator, parker *protocol.OEM750x) error
+		strategy protocol.HomingStrategy
+		position int
+	}{
+		{
+			name: "home switch",
+			setup: func(sim *simulator.Simulator, parker *protocol.OEM750x) error {
+				sim.SetHomePosition(1, -1200)
+				return nil
+			},
+			strategy: protocol.HomeSwitchHoming{Direction: protocol.Backward, HomingOptions: withOffset},
+			position: -900,
+		},
+		{
+			name: "encoder index",
+			setup: func(sim *simulator.Simulator, parker *protocol.OEM750x) error {
+				sim.SetHomePosition(1, -1200)
+				sim.SetEncoderIndex(1, -1180)
+				return parker.SetIndexerMode(1, protocol.EncoderSteps)
+			},
+			strategy: protocol.EncoderIndexHoming{Direction: protocol.Backward, HomingOptions: options},
+			position: -1180,
+		},
+		{
+			name:     "limit switch",
+			strategy: protocol.LimitSwitchHoming{Limit: protocol.CWLimit, HomingOptions: withBackoff},
+			position: 2850,
+		},
+		{
+			name: "hard stop",
+			setup: func(sim *simulator.Simulator, parker *protocol.OEM750x) error {
+				sim.SetHardStops(1, -800, 800)
+				return parker.SetIndexerMode(1, protocol.EncoderSteps)
+			},
+			strategy: protocol.HardStopHoming{Direction: protocol.Backward, HomingOptions: fromStop},
+			position: -650,
+		},
+		{
+			name:     "current position",
+			strategy: protocol.CurrentPositionHoming{HomingOptions: withOffset},
+			position: 300,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sim := simulator.New(simulator.Options{})
+			sim.SetTravel(1, -5000, 3000)
+			spy := &zeroSpy{Simulator: sim}
+			parker := oem750x.NewWithCommunication(spy)
+			parker.PollInterval = 5 * time.Millisecond
+			if err := parker.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer parker.Disconnect()
+			if err := parker.SetResolution(1, 200); err != nil {
+				t.Fatal(err)
+			} else if err := parker.SetTargetVelocity(1, 50); err != nil {
+				t.Fatal(err)
+			} else if err := parker.SetTargetAcceleration(1, 999); err != nil {
+				t.Fatal(err)
+			}
+			if c.setup != nil {
+				if err := c.setup(sim, parker); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := parker.Home(context.Background(), 1, c.strategy); err != nil {
+				t.Fatal(err)
+			}
+			if spy.zeroedAt == nil {
+				t.Fatal("the position was not set to zero")
+			}
+			if *spy.zeroedAt != c.position {
+				t.Fatalf("homed at %d, expected %d", *spy.zeroedAt, c.position)
+			}
+			if position, err := parker.GetAbsolutePosition(1); err != nil || position != 0 {
+				t.Fatalf("absolute position after homing = %d, %v", position, err)
+			}
+			if velocity, err := parker.GetTargetVelocity(1); err != nil || velocity != 50 {
+				t.Fatalf("velocity after homing = %g, %v", velocity, err)
+			}
+		})
+	}
+}
+
+func TestCurrentPositionHomingSetsModes(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetIncrementalMode(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parker.Home(context.Background(), 1, protocol.CurrentPositionHoming{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetDistance(1, 200); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for range 2 {
+		if err := parker.Go(1); err != nil {
+			t.Fatal(err)
+		} else if err := parker.WaitForMove(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sim.Position(1) != 200 {
+		t.Fatalf("expected normal and absolute moves to 200, position %d", sim.Position(1))
+	}
+}
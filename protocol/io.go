@@ -0,0 +1,265 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type Output uint
+type Input uint
+
+const (
+	Output1 Output = 1
+	Output2 Output = 2
+)
+
+/*
+Inputs numbered as the I/O bits reported by IS
+*/
+const (
+	InputTrigger1 Input = iota + 1
+	InputTrigger2
+	InputTrigger3
+	InputHome
+	InputFault
+	InputCCWLimit
+	InputCWLimit
+	InputSequenceSelect1
+	InputSequenceSelect2
+	InputSequenceSelect3
+)
+
+/*
+Names given by the application to the inputs and outputs
+of a channel
+*/
+type ioAliases struct {
+	outputs map[string]Output
+	inputs  map[string]Input
+}
+
+/*
+Names an output of the channel, so it can be set by name
+with SetOutput
+*/
+func (o *OEM750x) SetOutputAlias(channel uint, name string, output Output) error {
+	if name == "" {
+		return fmt.Errorf("%w: output alias must not be empty", ErrInvalidParameter)
+	}
+	if output != Output1 && output != Output2 {
+		return fmt.Errorf("%w: output %d", ErrInvalidParameter, output)
+	}
+	o.updateState(channel, func(state *channelState) {
+		if state.aliases.outputs == nil {
+			state.aliases.outputs = make(map[string]Output)
+		}
+		state.aliases.outputs[name] = output
+	})
+	return nil
+}
+
+/*
+Names an input of the channel, so it can be read by name
+with GetInput
+*/
+func (o *OEM750x) SetInputAlias(channel uint, name string, input Input) error {
+	if name == "" {
+		return fmt.Errorf("%w: input alias must not be empty", ErrInvalidParameter)
+	}
+	if _, exists := inputNames[input]; !exists {
+		return fmt.Errorf("%w: input %d", ErrInvalidParameter, input)
+	}
+	o.updateState(channel, func(state *channelState) {
+		if state.aliases.inputs == nil {
+			state.aliases.inputs = make(map[string]Input)
+		}
+		state.aliases.inputs[name] = input
+	})
+	return nil
+}
+
+/*
+Returns the output of an alias or of its number (1 or 2)
+*/
+func (o *OEM750x) output(channel uint, name string) (Output, error) {
+	o.stateMutex.Lock()
+	output, exists := o.state(channel).aliases.outputs[name]
+	o.stateMutex.Unlock()
+	if exists {
+		return output, nil
+	}
+	number, err := strconv.ParseUint(name, 10, 32)
+	if err != nil || (Output(number) != Output1 && Output(number) != Output2) {
+		return 0, fmt.Errorf("%w: unknown output %q", ErrInvalidParameter, name)
+	}
+	return Output(number), nil
+}
+
+/*
+Returns the input of an alias or of its name (e.g., trigger1
+or cw_limit)
+*/
+func (o *OEM750x) input(channel uint, name string) (Input, error) {
+	o.stateMutex.Lock()
+	input, exists := o.state(channel).aliases.inputs[name]
+	o.stateMutex.Unlock()
+	if exists {
+		return input, nil
+	}
+	if err := input.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("%w: unknown input %q", ErrInvalidParameter, name)
+	}
+	return input, nil
+}
+
+/*
+Sets the programmable outputs 1 and 2, where each character
+is '1' (on), '0' (off) or 'X' (unchanged). The command is
+buffered, so it takes effect after the pending commands
+*/
+func (o *OEM750x) SetOutputs(channel uint, pattern string) error {
+	return o.SetOutputsContext(context.Background(), channel, pattern)
+}
+
+/*
+Same as SetOutputs, giving up when the context is done
+*/
+func (o *OEM750x) SetOutputsContext(ctx context.Context, channel uint, pattern string) error {
+	if !validPattern(pattern, 2) {
+		return fmt.Errorf("%w: output pattern must have up to 2 characters of 1, 0 or X, got %q", ErrInvalidParameter, pattern)
+	}
+	msg := fmt.Sprintf("%dO%s", channel, pattern)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		for i := range pattern {
+			if pattern[i] != 'X' {
+				state.outputs[i] = pattern[i] == '1'
+			}
+		}
+	})
+	return nil
+}
+
+/*
+Turns an output on or off, given by its alias or its
+number, leaving the other output unchanged
+*/
+func (o *OEM750x) SetOutput(channel uint, name string, on bool) error {
+	return o.SetOutputContext(context.Background(), channel, name, on)
+}
+
+/*
+Same as SetOutput, giving up when the context is done
+*/
+func (o *OEM750x) SetOutputContext(ctx context.Context, channel uint, name string, on bool) error {
+	output, err := o.output(channel, name)
+	if err != nil {
+		return err
+	}
+	pattern := []byte("XX")
+	if on {
+		pattern[output-1] = '1'
+	} else {
+		pattern[output-1] = '0'
+	}
+	return o.SetOutputsContext(ctx, channel, string(pattern))
+}
+
+/*
+Returns the outputs 1 and 2 set through this instance, as
+the drive does not report them
+*/
+func (o *OEM750x) Outputs(channel uint) [2]bool {
+	return o.snapshotState(channel).outputs
+}
+
+/*
+Gets the hardware level of an input, given by its alias or
+its name, where true means high (opened)
+*/
+func (o *OEM750x) GetInput(channel uint, name string) (bool, error) {
+	return o.GetInputContext(context.Background(), channel, name)
+}
+
+/*
+Same as GetInput, giving up when the context is done
+*/
+func (o *OEM750x) GetInputContext(ctx context.Context, channel uint, name string) (bool, error) {
+	input, err := o.input(channel, name)
+	if err != nil {
+		return false, err
+	}
+	status, err := o.GetInputStatusContext(ctx, channel)
+	if err != nil {
+		return false, err
+	}
+	return status.Level(input), nil
+}
+
+/*
+Polls the trigger inputs every PollInterval until they match
+the pattern, where each character is '1' (high), '0' (low)
+or 'X' (ignored). Returns the last status read with the
+context error when the context is done first
+*/
+func (o *OEM750x) WaitForTriggers(ctx context.Context, channel uint, pattern string) (TriggerStatus, error) {
+	if !validPattern(pattern, 3) {
+		return TriggerStatus{}, fmt.Errorf("%w: trigger pattern must have up to 3 characters of 1, 0 or X, got %q", ErrInvalidParameter, pattern)
+	}
+	ticker := time.NewTicker(o.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		status, err := o.GetTriggerStatus(channel)
+		if err != nil {
+			return status, err
+		}
+		if status.Matches(pattern) {
+			return status, nil
+		}
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+Returns the hardware level of the input, where true means
+high (opened)
+*/
+func (i InputStatus) Level(input Input) bool {
+	switch input {
+	case InputTrigger1, InputTrigger2, InputTrigger3:
+		return i.Triggers[input-InputTrigger1]
+	case InputHome:
+		return i.Home
+	case InputFault:
+		return !i.Faulted
+	case InputCCWLimit:
+		return i.CCWLimit
+	case InputCWLimit:
+		return i.CWLimit
+	case InputSequenceSelect1, InputSequenceSelect2, InputSequenceSelect3:
+		return i.SequenceSelect[input-InputSequenceSelect1]
+	}
+	return false
+}
+
+/*
+Returns true if the triggers match the pattern, where each
+character is '1' (high), '0' (low) or 'X' (ignored)
+*/
+func (t TriggerStatus) Matches(pattern string) bool {
+	for i := 0; i < len(pattern) && i < len(t); i++ {
+		if pattern[i] != 'X' && (pattern[i] == '1') != t[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+const ioProfile = `
+channels:
+  - channel: 1
+    outputs:
+      clamp: 2
+    inputs:
+      door: trigger2
+`
+
+func TestDigitalIO(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+	ctx := context.Background()
+
+	config, err := protocol.ParseConfigYAML([]byte(ioProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Apply(ctx, config.Channels...); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parker.SetOutput(1, "clamp", true); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetOutput(1, "1", true); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetOutput(1, "1", false); err != nil {
+		t.Fatal(err)
+	}
+	if outputs := sim.Outputs(1); outputs != [2]bool{false, true} || parker.Outputs(1) != outputs {
+		t.Fatalf("outputs = %v, tracked %v", outputs, parker.Outputs(1))
+	}
+	if err := parker.SetOutput(1, "valve", true); !errors.Is(err, protocol.ErrInvalidParameter) {
+		t.Fatalf("unknown output returned %v", err)
+	}
+
+	sim.SetTriggers(1, [3]bool{false, true, false})
+	if door, err := parker.GetInput(1, "door"); err != nil || !door {
+		t.Fatalf("door = %v, %v", door, err)
+	}
+	if home, err := parker.GetInput(1, "home"); err != nil || home {
+		t.Fatalf("home = %v, %v", home, err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		sim.SetTriggers(1, [3]bool{true, true, false})
+	}()
+	wait, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if status, err := parker.WaitForTriggers(wait, 1, "11"); err != nil || status.String() != "110" {
+		t.Fatalf("wait for triggers = %s, %v", status, err)
+	}
+
+	wait, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := parker.WaitForTriggers(wait, 1, "XX1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait for trigger 3 returned %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+Time a jog keeps running without being refreshed when
+the OEM750x JogDeadman is zero
+*/
+const DefaultJogDeadman = 500 * time.Millisecond
+
+/*
+Continuous move of a channel held by the caller, with the
+settings to restore when it ends
+*/
+type jog struct {
+	heartbeat  chan struct{}
+	stop       chan chan error
+	done       chan struct{}
+	continuous bool
+	direction  int
+	velocity   float64
+	failure    error
+}
+
+/*
+Returns the window in which a jog must be refreshed
+*/
+func (o *OEM750x) jogDeadman() time.Duration {
+	if o.JogDeadman <= 0 {
+		return DefaultJogDeadman
+	}
+	return o.JogDeadman
+}
+
+/*
+Starts a continuous move of the channel in the direction
+and at the speed (rps) given, that lasts while it is
+refreshed with RefreshJog. When no refresh arrives within
+JogDeadman (e.g., the user interface or the network was
+lost), the motor is stopped (S) automatically, falling back
+to kill (K) when S fails. The jog stays registered until the
+motor is stopped, and the deadman keeps trying every window.
+When the jog ends, the previous motion mode, direction and
+velocity are restored. A jog already running on the channel is ended
+first. Software limits are enforced as for Go
+*/
+func (o *OEM750x) StartJog(channel uint, direction Direction, speed float64) error {
+	if err := checkRange("speed", speed, MinVelocity, MaxVelocity); err != nil {
+		return err
+	}
+	if direction != Forward && direction != Backward {
+		return fmt.Errorf("%w: direction must be '+' (forward) or '-' (backward), got %q", ErrInvalidParameter, direction)
+	}
+	if o.IsJogging(channel) {
+		if err := o.StopJog(channel); err != nil {
+			return err
+		}
+	}
+
+	state := o.snapshotState(channel)
+	j := &jog{
+		heartbeat:  make(chan struct{}, 1),
+		stop:       make(chan chan error),
+		done:       make(chan struct{}),
+		continuous: state.continuous,
+		direction:  state.direction,
+		velocity:   state.velocity,
+	}
+	if j.velocity == 0 {
+		velocity, err := o.GetTargetVelocity(channel)
+		if err != nil {
+			return err
+		}
+		j.velocity = velocity
+	}
+
+	if err := o.SetContinuosMode(channel); err != nil {
+		return errors.Join(err, o.restoreJog(channel, j))
+	} else if err := o.SetDirection(channel, direction); err != nil {
+		return errors.Join(err, o.restoreJog(channel, j))
+	} else if err := o.SetTargetVelocity(channel, speed); err != nil {
+		return errors.Join(err, o.restoreJog(channel, j))
+	} else if err := o.Go(channel); err != nil {
+		return errors.Join(err, o.restoreJog(channel, j))
+	}
+
+	o.stateMutex.Lock()
+	if o.jogs == nil {
+		o.jogs = make(map[uint]*jog)
+	}
+	o.jogs[channel] = j
+	o.stateMutex.Unlock()
+	o.updateState(channel, func(state *channelState) {
+		state.jogErr = nil
+	})
+	go o.holdJog(channel, j, o.jogDeadman())
+	return nil
+}
+
+/*
+Keeps the jog of the channel running for another
+JogDeadman window. Returns ErrNotJogging when the jog
+has already ended, such as after the deadman fired, along
+with the error restoring the settings after the deadman,
+and the error of the deadman while it fails to stop the motor
+*/
+func (o *OEM750x) RefreshJog(channel uint) error {
+	o.stateMutex.Lock()
+	j := o.jogs[channel]
+	var failure error
+	if j != nil {
+		failure = j.failure
+	}
+	o.stateMutex.Unlock()
+	if j == nil {
+		return errors.Join(fmt.Errorf("channel %d: %w", channel, ErrNotJogging), o.snapshotState(channel).jogErr)
+	}
+	if failure != nil {
+		return fmt.Errorf("channel %d: deadman could not stop the jog: %w", channel, failure)
+	}
+	select {
+	case j.heartbeat <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+/*
+Ends the jog of the channel, stopping the motor and
+restoring the previous settings. The motor is stopped
+even if no jog is running. When the motor cannot be
+stopped, the jog keeps running under the deadman
+*/
+func (o *OEM750x) StopJog(channel uint) error {
+	o.stateMutex.Lock()
+	j := o.jogs[channel]
+	o.stateMutex.Unlock()
+	if j == nil {
+		return o.Stop(channel)
+	}
+	reply := make(chan error)
+	select {
+	case j.stop <- reply:
+		return <-reply
+	case <-j.done:
+		return o.Stop(channel)
+	}
+}
+
+/*
+Returns true if the channel is jogging
+*/
+func (o *OEM750x) IsJogging(channel uint) bool {
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	return o.jogs[channel] != nil
+}
+
+/*
+Waits for the refreshes of the jog, ending it when it is
+stopped or the deadman window elapses. When the motor cannot
+be stopped the jog stays registered, and after the deadman
+fired it tries again every window, ignoring the refreshes
+*/
+func (o *OEM750x) holdJog(channel uint, j *jog, deadman time.Duration) {
+	timer := time.NewTimer(deadman)
+	defer timer.Stop()
+	defer close(j.done)
+
+	expired := false
+	for {
+		select {
+		case <-j.heartbeat:
+			if !expired {
+				timer.Reset(deadman)
+			}
+		case reply := <-j.stop:
+			if err := o.forceStop(channel); err != nil {
+				reply <- err
+				continue
+			}
+			reply <- o.finishJog(channel, j)
+			return
+		case <-timer.C:
+			if err := o.forceStop(channel); err != nil {
+				expired = true
+				o.stateMutex.Lock()
+				j.failure = err
+				o.stateMutex.Unlock()
+				timer.Reset(deadman)
+				continue
+			}
+			if err := o.finishJog(channel, j); err != nil {
+				o.updateState(channel, func(state *channelState) {
+					state.jogErr = fmt.Errorf("channel %d: deadman could not restore the jog settings: %w", channel, err)
+				})
+			}
+			return
+		}
+	}
+}
+
+/*
+Removes the stopped jog of the channel and restores the
+settings it had before
+*/
+func (o *OEM750x) finishJog(channel uint, j *jog) error {
+	o.stateMutex.Lock()
+	if o.jogs[channel] == j {
+		delete(o.jogs, channel)
+	}
+	o.stateMutex.Unlock()
+	return o.restoreJog(channel, j)
+}
+
+func (o *OEM750x) restoreJog(channel uint, j *jog) error {
+	if !j.continuous {
+		if err := o.SetNormalMode(channel); err != nil {
+			return err
+		}
+	}
+	direction := Forward
+	if j.direction < 0 {
+		direction = Backward
+	}
+	if err := o.SetDirection(channel, direction); err != nil {
+		return err
+	}
+	return o.SetTargetVelocity(channel, j.velocity)
+}
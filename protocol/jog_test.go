@@ -0,0 +1,124 @@
+package protocol_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestJogDeadman(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+	parker.JogDeadman = 50 * time.Millisecond
+
+	if err := parker.StartJog(1, protocol.Backward, 5); err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		time.Sleep(25 * time.Millisecond)
+		if err := parker.RefreshJog(1); err != nil {
+			t.Fatalf("refresh within the window failed: %v", err)
+		}
+	}
+	if !sim.IsMoving(1) || sim.Position(1) >= 0 {
+		t.Fatalf("expected the refreshed jog to keep moving, position %d", sim.Position(1))
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if err := parker.RefreshJog(1); !errors.Is(err, protocol.ErrNotJogging) {
+		t.Fatalf("expected the deadman to end the jog, got %v", err)
+	}
+	if sim.IsMoving(1) {
+		t.Fatal("expected the deadman to stop the motor")
+	}
+	if velocity, err := parker.GetTargetVelocity(1); err != nil || velocity != 50 {
+		t.Fatalf("velocity after the jog = %g, %v", velocity, err)
+	}
+
+	if err := parker.StartJog(1, protocol.Forward, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.StopJog(1); err != nil || parker.IsJogging(1) {
+		t.Fatalf("stop jog = %v, jogging %v", err, parker.IsJogging(1))
+	}
+	if velocity, err := parker.GetTargetVelocity(1); err != nil || velocity != 50 {
+		t.Fatalf("velocity after stopping the jog = %g, %v", velocity, err)
+	}
+}
+
+func TestJogDeadmanKeepsTryingToStop(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	transport := &failingTransport{Unicomm: sim}
+	parker := oem750x.NewWithCommunication(transport)
+	parker.PollInterval = 5 * time.Millisecond
+	parker.JogDeadman = 30 * time.Millisecond
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	if err := parker.StartJog(1, protocol.Forward, 1); err != nil {
+		t.Fatal(err)
+	}
+	transport.failing("1S", "1K")
+	time.Sleep(100 * time.Millisecond)
+	if err := parker.RefreshJog(1); err == nil || errors.Is(err, protocol.ErrNotJogging) {
+		t.Fatalf("expected the failure of the deadman, got %v", err)
+	}
+	if !parker.IsJogging(1) || !sim.IsMoving(1) {
+		t.Fatal("expected the jog to stay registered while the motor runs")
+	}
+
+	transport.failing("1S")
+	time.Sleep(100 * time.Millisecond)
+	if err := parker.RefreshJog(1); !errors.Is(err, protocol.ErrNotJogging) {
+		t.Fatalf("expected the deadman to end the jog, got %v", err)
+	}
+	if sim.IsMoving(1) {
+		t.Fatal("expected the kill to stop the motor")
+	}
+}
+
+func TestJogDeadmanReportsRestoreFailure(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	transport := &failingTransport{Unicomm: sim}
+	parker := oem750x.NewWithCommunication(transport)
+	parker.PollInterval = 5 * time.Millisecond
+	parker.JogDeadman = 30 * time.Millisecond
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	if err := parker.SetTargetVelocity(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.StartJog(1, protocol.Forward, 1); err != nil {
+		t.Fatal(err)
+	}
+	transport.failing("1V2.00")
+	time.Sleep(100 * time.Millisecond)
+	err := parker.RefreshJog(1)
+	if !errors.Is(err, protocol.ErrNotJogging) || !strings.Contains(err.Error(), "restore") {
+		t.Fatalf("expected the failed restore to be reported, got %v", err)
+	}
+	if sim.IsMoving(1) {
+		t.Fatal("expected the deadman to stop the motor")
+	}
+
+	transport.failing()
+	if err := parker.StartJog(1, protocol.Forward, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.StopJog(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.RefreshJog(1); err == nil || strings.Contains(err.Error(), "restore") {
+		t.Fatalf("expected the failure to be cleared by the next jog, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+/*
+Software travel limits of a channel as the minimum and
+maximum absolute positions in steps
+*/
+type SoftLimits struct {
+	Min int
+	Max int
+}
+
+/*
+Error returned when a move is rejected because its target
+is outside of the software travel limits
+*/
+type SoftLimitError struct {
+	Channel uint
+	Target  int
+	Limits  SoftLimits
+}
+
+func (e *SoftLimitError) Error() string {
+	return fmt.Sprintf(
+		"target %d of channel %d is outside of the software limits [%d, %d]",
+		e.Target, e.Channel, e.Limits.Min, e.Limits.Max,
+	)
+}
+
+func (e *SoftLimitError) Unwrap() error {
+	return ErrSoftLimit
+}
+
+/*
+Sets the software travel limits of the channel that are
+enforced by the library before every go command. Continuous
+moves are watched and stopped before reaching the boundary
+
+Note: the positioning and motion modes are tracked from the
+commands sent by this instance, starting from the power-up
+values of the indexer (MN and MPI)
+*/
+func (o *OEM750x) SetSoftLimits(channel uint, limits SoftLimits) error {
+	if limits.Min > limits.Max {
+		return fmt.Errorf("%w: minimum limit %d is greater than maximum limit %d", ErrInvalidParameter, limits.Min, limits.Max)
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.limits = &limits
+	})
+	return nil
+}
+
+/*
+Removes the software travel limits of the channel
+*/
+func (o *OEM750x) ClearSoftLimits(channel uint) {
+	o.updateState(channel, func(state *channelState) {
+		state.limits = nil
+	})
+}
+
+/*
+Gets the software travel limits of the channel, returning
+false when the channel has no limits
+*/
+func (o *OEM750x) GetSoftLimits(channel uint) (SoftLimits, bool) {
+	state := o.snapshotState(channel)
+	if state.limits == nil {
+		return SoftLimits{}, false
+	}
+	return *state.limits, true
+}
+
+/*
+Continuous move that must be stopped before the boundary
+*/
+type limitWatch struct {
+	channel   uint
+	start     int
+	direction int
+	margin    int
+	limits    SoftLimits
+	watch     uint64
+}
+
+/*
+Checks the target of the next go command against the software
+limits of the channel. Returns a watch for continuous moves,
+or ErrBusy when the indexer is still moving
+*/
+func (o *OEM750x) checkSoftLimits(ctx context.Context, channel uint) (*limitWatch, error) {
+	state := o.snapshotState(channel)
+	if state.limits == nil {
+		return nil, nil
+	}
+	limits := *state.limits
+	if err := o.checkIdle(ctx, channel); err != nil {
+		return nil, err
+	}
+	position, err := o.GetAbsolutePositionContext(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.continuous {
+		if (state.direction > 0 && position >= limits.Max) ||
+			(state.direction < 0 && position <= limits.Min) {
+			return nil, &SoftLimitError{Channel: channel, Target: position, Limits: limits}
+		}
+		margin, err := o.stoppingDistance(channel, state)
+		if err != nil {
+			return nil, err
+		}
+		return &limitWatch{
+			channel:   channel,
+			start:     position,
+			direction: state.direction,
+			margin:    margin,
+			limits:    limits,
+		}, nil
+	}
+
+	distance := state.distance
+	if !state.hasDistance {
+		if distance, err = o.GetTargetDistanceContext(ctx, channel); err != nil {
+			return nil, err
+		}
+	}
+	target := distance
+	if !state.absolute {
+		if distance < 0 {
+			distance = -distance
+		}
+		target = position + state.direction*distance
+	}
+	if target < limits.Min || target > limits.Max {
+		return nil, &SoftLimitError{Channel: channel, Target: target, Limits: limits}
+	}
+	return nil, nil
+}
+
+/*
+Returns the number of steps the motor needs to decelerate
+from the target velocity plus the distance travelled
+during one poll interval
+*/
+func (o *OEM750x) stoppingDistance(channel uint, state channelState) (int, error) {
+	velocity, acceleration, resolution, err := o.motionParameters(channel, state)
+	if err != nil {
+		return 0, err
+	}
+	steps := float64(resolution) * velocity
+	decel := steps * velocity / (2 * acceleration)
+	travel := steps * o.pollInterval().Seconds()
+	return int(math.Ceil(decel + travel)), nil
+}
+
+/*
+Returns the velocity, acceleration and resolution of the
+state, reading from the drive the ones that were not set
+through this instance
+*/
+func (o *OEM750x) motionParameters(channel uint, state channelState) (float64, float64, uint, error) {
+	var err error
+	velocity, acceleration, resolution := state.velocity, state.acceleration, state.resolution
+	if velocity == 0 {
+		if velocity, err = o.GetTargetVelocity(channel); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	if acceleration == 0 {
+		if acceleration, err = o.GetTargetAcceleration(channel); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	if resolution == 0 {
+		value, err := o.GetResolution(channel)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		resolution = uint(value)
+	}
+	return velocity, acceleration, resolution, nil
+}
+
+/*
+Starts watching a continuous move, replacing any previous
+watch of the same channel
+*/
+func (o *OEM750x) startWatch(watch *limitWatch) {
+	o.updateState(watch.channel, func(state *channelState) {
+		state.watch++
+		state.watchErr = nil
+		watch.watch = state.watch
+	})
+	go o.watchSoftLimits(watch)
+}
+
+/*
+Returns the error that ended the software limit watch of
+the last continuous move of the channel: the status or
+position could not be read, so the motor was stopped, or
+the motor could not be stopped at all. Returns nil while
+the watch runs or when it ended normally
+*/
+func (o *OEM750x) WatchError(channel uint) error {
+	return o.snapshotState(channel).watchErr
+}
+
+/*
+Polls the position of a continuous move and stops the motor
+when the boundary is within the stopping distance. Returns
+when the indexer is ready or the watch is replaced. A move
+that cannot be watched is stopped too, and the errors are
+kept for WatchError
+*/
+func (o *OEM750x) watchSoftLimits(watch *limitWatch) {
+	ticker := time.NewTicker(o.pollInterval())
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if o.snapshotState(watch.channel).watch != watch.watch {
+			return
+		}
+		status, err := o.GetIndexerStatus(watch.channel)
+		if err != nil {
+			o.endWatch(watch, err)
+			return
+		}
+		if status == IndexerReady || status == IndexerReadyAttention {
+			return
+		}
+		relative, err := o.GetRelativePosition(watch.channel)
+		if err != nil {
+			o.endWatch(watch, err)
+			return
+		}
+		position := watch.start + relative
+		if (watch.direction > 0 && position+watch.margin >= watch.limits.Max) ||
+			(watch.direction < 0 && position-watch.margin <= watch.limits.Min) {
+			o.endWatch(watch, nil)
+			return
+		}
+	}
+}
+
+/*
+Stops the watched motor and keeps the cause and the error
+of the stop, if any, for WatchError
+*/
+func (o *OEM750x) endWatch(watch *limitWatch, cause error) {
+	err := o.forceStop(watch.channel)
+	if cause != nil {
+		cause = fmt.Errorf("channel %d: soft limit watch stopped the motor: %w", watch.channel, cause)
+	}
+	if err != nil {
+		err = fmt.Errorf("channel %d: soft limit watch could not stop the motor: %w", watch.channel, err)
+	}
+	if err = errors.Join(cause, err); err == nil {
+		return
+	}
+	o.updateState(watch.channel, func(state *channelState) {
+		if state.watch == watch.watch {
+			state.watchErr = err
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestSoftLimitsRejectMoves(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+	ctx := context.Background()
+
+	if err := parker.SetSoftLimits(1, protocol.SoftLimits{Min: 10, Max: -10}); !errors.Is(err, protocol.ErrInvalidParameter) {
+		t.Fatal("expected an error for inverted limits")
+	}
+	if err := parker.SetSoftLimits(1, protocol.SoftLimits{Min: -1000, Max: 1000}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		move   func() error
+		target int
+	}{
+		{"absolute", func() error { return parker.MoveTo(ctx, 1, 1500) }, 1500},
+		{"relative", func() error { return parker.MoveBy(ctx, 1, -1200) }, -1200},
+	}
+	for _, test := range tests {
+		err := test.move()
+		var limitErr *protocol.SoftLimitError
+		if !errors.Is(err, protocol.ErrSoftLimit) || !errors.As(err, &limitErr) {
+			t.Fatalf("%s: expected a soft limit error, got %v", test.name, err)
+		}
+		if limitErr.Target != test.target {
+			t.Fatalf("%s: target = %d", test.name, limitErr.Target)
+		}
+		if sim.IsMoving(1) || sim.Position(1) != 0 {
+			t.Fatalf("%s: motor moved to %d", test.name, sim.Position(1))
+		}
+	}
+
+	if err := parker.MoveTo(ctx, 1, 900); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.MoveBy(ctx, 1, 200); !errors.Is(err, protocol.ErrSoftLimit) {
+		t.Fatalf("expected a soft limit error, got %v", err)
+	}
+
+	parker.ClearSoftLimits(1)
+	if _, ok := parker.GetSoftLimits(1); ok {
+		t.Fatal("limits were not cleared")
+	}
+	if err := parker.MoveBy(ctx, 1, 200); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSoftLimitsStopContinuousMove(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+
+	if err := parker.SetTargetVelocity(1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetSoftLimits(1, protocol.SoftLimits{Min: -2000, Max: 2000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetDirection(1, protocol.Forward); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sim.IsMoving(1) {
+		if time.Now().After(deadline) {
+			t.Fatal("continuous move was not stopped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if position := sim.Position(1); position > 2000 || position < 1000 {
+		t.Fatalf("continuous move stopped at %d", position)
+	}
+
+	if err := parker.SetSoftLimits(1, protocol.SoftLimits{Min: -2000, Max: sim.Position(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(1); !errors.Is(err, protocol.ErrSoftLimit) {
+		t.Fatalf("expected a soft limit error at the boundary, got %v", err)
+	}
+}
+
+func TestSoftLimitWatchFailure(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	transport := &failingTransport{Unicomm: sim}
+	parker := oem750x.NewWithCommunication(transport)
+	parker.PollInterval = 5 * time.Millisecond
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	if err := parker.SetTargetVelocity(1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetSoftLimits(1, protocol.SoftLimits{Min: -100000, Max: 100000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	}
+	start := func(failing ...string) {
+		t.Helper()
+		if err := parker.Go(1); err != nil {
+			t.Fatal(err)
+		}
+		transport.failing(failing...)
+		deadline := time.Now().Add(2 * time.Second)
+		for parker.WatchError(1) == nil {
+			if time.Now().After(deadline) {
+				t.Fatal("the watch did not report the failure")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		transport.failing()
+	}
+
+	start("1R")
+	if sim.IsMoving(1) {
+		t.Fatal("the unwatched move was not stopped")
+	}
+
+	start("1R", "1S", "1K")
+	if err := parker.WatchError(1); !strings.Contains(err.Error(), "could not stop") {
+		t.Fatalf("expected the failed stop to be reported, got %v", err)
+	}
+	if err := parker.Kill(1); err != nil {
+		t.Fatal(err)
+	}
+}
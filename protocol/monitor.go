@@ -0,0 +1,419 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMonitorInterval time.Duration = 100 * time.Millisecond
+	DefaultEventBuffer     int           = 64
+)
+
+type EventType uint
+
+const (
+	MoveStarted EventType = iota
+	MoveFinished
+	LimitHit
+	AttentionRaised
+	StallDetected
+	PositionChanged
+	PollFailed
+	RecoveryFailed
+)
+
+func (t EventType) String() string {
+	switch t {
+	case MoveStarted:
+		return "move started"
+	case MoveFinished:
+		return "move finished"
+	case LimitHit:
+		return "limit hit"
+	case AttentionRaised:
+		return "attention raised"
+	case StallDetected:
+		return "stall detected"
+	case PositionChanged:
+		return "position changed"
+	case PollFailed:
+		return "poll failed"
+	case RecoveryFailed:
+		return "recovery failed"
+	}
+	return fmt.Sprintf("unknown (%d)", uint(t))
+}
+
+/*
+Latest status of a channel read by the monitor. The position
+is read with PR while the indexer is ready and estimated from
+the start position and W3 while it is busy, as PR is buffered.
+The estimate is kept from the last ready position, so it is only
+exact for the first move of a command string or sequence. RC is
+buffered too, so the closed loop status is kept from the last
+ready poll while the indexer is busy
+*/
+type Snapshot struct {
+	Channel    uint
+	Time       time.Time
+	Indexer    IndexerStatus
+	Limits     LimitStatus
+	ClosedLoop ClosedLoopStatus
+	Position   int
+	Err        error
+}
+
+/*
+Returns true while the indexer is executing commands
+*/
+func (s Snapshot) Busy() bool {
+	return s.Indexer == IndexerBusy || s.Indexer == IndexerBusyAttention
+}
+
+/*
+Returns true if the indexer requires attention
+*/
+func (s Snapshot) Attention() bool {
+	return s.Indexer == IndexerReadyAttention || s.Indexer == IndexerBusyAttention
+}
+
+/*
+Event published by the monitor with the snapshot that raised
+it. Stall is only set for the StallDetected events
+*/
+type Event struct {
+	Type     EventType
+	Channel  uint
+	Snapshot Snapshot
+	Stall    *StallEvent
+}
+
+/*
+Options of the monitor
+
+  - Channels: addresses polled by the monitor
+  - Interval: time between polls (default is 100 ms)
+  - Buffer: size of the channel of each subscriber (default is 64)
+*/
+type MonitorOptions struct {
+	Channels []uint
+	Interval time.Duration
+	Buffer   int
+}
+
+type subscription struct {
+	events chan Event
+	types  map[EventType]bool
+}
+
+/*
+Polls the status of a set of channels in the background,
+caching the latest snapshot and publishing events to the
+subscribers. A single monitor replaces several goroutines
+polling the same drive
+*/
+type Monitor struct {
+	Drive       *OEM750x
+	options     MonitorOptions
+	mutex       sync.Mutex
+	snapshots   map[uint]Snapshot
+	starts      map[uint]int
+	subscribers map[*subscription]bool
+	recoveries  map[uint]StallRecovery
+	recovering  map[uint]bool
+	running     sync.WaitGroup
+	closed      bool
+}
+
+/*
+Creates a new monitor of the drive channels
+*/
+func NewMonitor(drive *OEM750x, options MonitorOptions) (*Monitor, error) {
+	if len(options.Channels) == 0 {
+		return nil, fmt.Errorf("%w: monitor requires at least one channel", ErrInvalidParameter)
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultMonitorInterval
+	}
+	if options.Buffer <= 0 {
+		options.Buffer = DefaultEventBuffer
+	}
+	options.Channels = append([]uint(nil), options.Channels...)
+	return &Monitor{
+		Drive:       drive,
+		options:     options,
+		snapshots:   make(map[uint]Snapshot),
+		starts:      make(map[uint]int),
+		subscribers: make(map[*subscription]bool),
+		recoveries:  make(map[uint]StallRecovery),
+		recovering:  make(map[uint]bool),
+	}, nil
+}
+
+/*
+Subscribes to the events of the given types, or to every
+event when no type is given. Events are dropped when the
+subscriber does not keep up with the buffer. The channel is
+closed by the returned cancel function or when the monitor stops
+*/
+func (m *Monitor) Subscribe(types ...EventType) (<-chan Event, func()) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	sub := &subscription{events: make(chan Event, m.options.Buffer)}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool)
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	if m.closed {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+	m.subscribers[sub] = true
+	cancel := func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		if m.subscribers[sub] {
+			delete(m.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return sub.events, cancel
+}
+
+/*
+Registers the recovery run when a stall is detected on the
+channel, replacing the previous one, or removes it when nil.
+The recovery runs in its own goroutine while the monitor keeps
+polling, and a new stall of the channel is not recovered until
+it returns. The errors are published as RecoveryFailed events
+*/
+func (m *Monitor) OnStall(channel uint, recovery StallRecovery) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if recovery == nil {
+		delete(m.recoveries, channel)
+		return
+	}
+	m.recoveries[channel] = recovery
+}
+
+/*
+Returns the latest snapshot of the channel, false when it
+was not polled yet
+*/
+func (m *Monitor) Snapshot(channel uint) (Snapshot, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	snapshot, exists := m.snapshots[channel]
+	return snapshot, exists
+}
+
+/*
+Polls the channels until the context is done, then waits
+for the running recoveries and closes the channels of every
+subscriber. It blocks, so it is usually started in its own
+goroutine, and must be called only once per monitor
+*/
+func (m *Monitor) Run(ctx context.Context) error {
+	defer m.close()
+	defer m.running.Wait()
+
+	ticker := time.NewTicker(m.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		for _, channel := range m.options.Channels {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			m.poll(ctx, channel)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+Closes the channels of every subscriber
+*/
+func (m *Monitor) close() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.closed = true
+	for sub := range m.subscribers {
+		close(sub.events)
+	}
+	clear(m.subscribers)
+}
+
+/*
+Reads the status of the channel, stores the snapshot and
+publishes the events of the changes since the previous one.
+RC is only read while the indexer is ready, so a stall is
+checked when the move ends or when the flag rises between
+moves, and not on the busy polls that keep the last status
+*/
+func (m *Monitor) poll(ctx context.Context, channel uint) {
+	m.mutex.Lock()
+	previous, polled := m.snapshots[channel]
+	start, hasStart := m.starts[channel]
+	m.mutex.Unlock()
+
+	current := Snapshot{
+		Channel:    channel,
+		Time:       time.Now(),
+		ClosedLoop: previous.ClosedLoop,
+		Position:   previous.Position,
+	}
+	current.Err = m.read(&current, start, hasStart)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if current.Err != nil {
+		current.Indexer = previous.Indexer
+		current.Limits = previous.Limits
+		current.ClosedLoop = previous.ClosedLoop
+		m.snapshots[channel] = current
+		m.publish(PollFailed, current)
+		return
+	}
+	if !current.Busy() {
+		m.starts[channel] = current.Position
+	}
+	m.snapshots[channel] = current
+	if !polled {
+		return
+	}
+
+	if current.Busy() && !previous.Busy() {
+		m.publish(MoveStarted, current)
+	}
+	if current.Position != previous.Position {
+		m.publish(PositionChanged, current)
+	}
+	if rising(previous.Limits.LastMoveStoppedByCW, current.Limits.LastMoveStoppedByCW) ||
+		rising(previous.Limits.LastMoveStoppedByCCW, current.Limits.LastMoveStoppedByCCW) ||
+		rising(previous.Limits.CWActive, current.Limits.CWActive) ||
+		rising(previous.Limits.CCWActive, current.Limits.CCWActive) {
+		m.publish(LimitHit, current)
+	}
+	if current.Attention() && !previous.Attention() {
+		m.publish(AttentionRaised, current)
+	}
+	if !current.Busy() && current.ClosedLoop.Stall && (previous.Busy() || !previous.ClosedLoop.Stall) {
+		stall := &StallEvent{
+			Channel:   channel,
+			Time:      current.Time,
+			Position:  current.Position,
+			Direction: m.Drive.moveDirection(channel, start, current.Position),
+		}
+		m.publishEvent(Event{Type: StallDetected, Channel: channel, Snapshot: current, Stall: stall})
+		m.recover(ctx, *stall)
+	}
+	if !current.Busy() && previous.Busy() {
+		m.publish(MoveFinished, current)
+	}
+}
+
+/*
+Reads the status reports of the channel into the snapshot.
+The buffered reports (RC and PR) are only read while the
+indexer is ready, since the drive does not answer them
+until the move ends
+*/
+func (m *Monitor) read(snapshot *Snapshot, start int, hasStart bool) error {
+	var err error
+	channel := snapshot.Channel
+	if snapshot.Indexer, err = m.Drive.GetIndexerStatus(channel); err != nil {
+		return err
+	}
+	if snapshot.Limits, err = m.Drive.ReadLimitStatus(channel); err != nil {
+		return err
+	}
+	if !snapshot.Busy() {
+		if snapshot.ClosedLoop, err = m.Drive.ReadClosedLoopStatus(channel); err != nil {
+			return err
+		}
+		snapshot.Position, err = m.Drive.GetAbsolutePosition(channel)
+		return err
+	}
+	if hasStart {
+		relative, err := m.Drive.GetRelativePosition(channel)
+		if err != nil {
+			return err
+		}
+		snapshot.Position = start + relative
+	}
+	return nil
+}
+
+/*
+Starts the recovery of the channel, unless there is none or
+it is still running. The monitor mutex must be held by the caller
+*/
+func (m *Monitor) recover(ctx context.Context, stall StallEvent) {
+	recovery := m.recoveries[stall.Channel]
+	if recovery == nil || m.recovering[stall.Channel] {
+		return
+	}
+	m.recovering[stall.Channel] = true
+	m.running.Add(1)
+	go func() {
+		defer m.running.Done()
+		err := recovery(ctx, m.Drive, stall)
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		m.recovering[stall.Channel] = false
+		if err != nil {
+			snapshot := m.snapshots[stall.Channel]
+			snapshot.Time = time.Now()
+			snapshot.Err = err
+			m.publish(RecoveryFailed, snapshot)
+		}
+	}()
+}
+
+/*
+Sends an event of the snapshot to the subscribers of its
+type. The monitor mutex must be held by the caller
+*/
+func (m *Monitor) publish(t EventType, snapshot Snapshot) {
+	m.publishEvent(Event{Type: t, Channel: snapshot.Channel, Snapshot: snapshot})
+}
+
+/*
+Sends the event to the subscribers of its type without
+blocking. The monitor mutex must be held by the caller
+*/
+func (m *Monitor) publishEvent(event Event) {
+	t := event.Type
+	for sub := range m.subscribers {
+		if sub.types != nil && !sub.types[t] {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+func rising(previous bool, current bool) bool {
+	return !previous && current
+}
@@ -0,0 +1,143 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestMonitorPublishesEvents(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	sim.SetTravel(1, -1000, 3000)
+	parker := newFastAxis(t, sim)
+
+	if _, err := protocol.NewMonitor(parker, protocol.MonitorOptions{}); !errors.Is(err, protocol.ErrInvalidParameter) {
+		t.Fatalf("expected an error for a monitor without channels, got %v", err)
+	}
+	monitor, err := protocol.NewMonitor(parker, protocol.MonitorOptions{
+		Channels: []uint{1},
+		Interval: 2 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ := monitor.Subscribe()
+	finished, unsubscribe := monitor.Subscribe(protocol.MoveFinished)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- monitor.Run(ctx) }()
+
+	for {
+		if _, ok := monitor.Snapshot(1); ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[protocol.EventType]bool)
+	timeout := time.After(5 * time.Second)
+	for !seen[protocol.MoveFinished] {
+		select {
+		case event := <-events:
+			if event.Channel != 1 {
+				t.Fatalf("unexpected channel %d", event.Channel)
+			}
+			seen[event.Type] = true
+		case <-timeout:
+			t.Fatalf("missing events, got %v", seen)
+		}
+	}
+	for _, expected := range []protocol.EventType{
+		protocol.MoveStarted, protocol.PositionChanged, protocol.LimitHit, protocol.AttentionRaised,
+	} {
+		if !seen[expected] {
+			t.Fatalf("missing %s event, got %v", expected, seen)
+		}
+	}
+	if event := <-finished; event.Type != protocol.MoveFinished || event.Snapshot.Position != 3000 {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	unsubscribe()
+	if _, ok := <-finished; ok {
+		t.Fatal("subscription was not closed")
+	}
+
+	snapshot, _ := monitor.Snapshot(1)
+	if !snapshot.Limits.CWActive || !snapshot.Attention() || snapshot.Position != 3000 {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("run returned %v", err)
+	}
+	for range events {
+	}
+}
+
+func TestMonitorPollsDuringMove(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+	if err := parker.SetTargetVelocity(1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor, err := protocol.NewMonitor(parker, protocol.MonitorOptions{
+		Channels: []uint{1},
+		Interval: 2 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ := monitor.Subscribe(protocol.MoveStarted, protocol.PositionChanged, protocol.PollFailed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- monitor.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for {
+		if _, ok := monitor.Snapshot(1); ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Kill(1)
+
+	seen := make(map[protocol.EventType]bool)
+	timeout := time.After(5 * time.Second)
+	for !seen[protocol.MoveStarted] || !seen[protocol.PositionChanged] {
+		select {
+		case event := <-events:
+			if event.Type == protocol.PollFailed {
+				t.Fatalf("poll failed during the move: %v", event.Snapshot.Err)
+			}
+			if !event.Snapshot.Busy() || !sim.IsMoving(1) {
+				t.Fatalf("%s event while the motor is not moving", event.Type)
+			}
+			seen[event.Type] = true
+		case <-timeout:
+			t.Fatalf("missing events during the move, got %v", seen)
+		}
+	}
+}
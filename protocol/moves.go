@@ -0,0 +1,269 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPollInterval time.Duration = 50 * time.Millisecond
+)
+
+/*
+Error returned by the blocking moves with the state of
+the axis when the move was interrupted
+*/
+type MoveError struct {
+	Channel  uint
+	Target   int
+	Position int
+	Status   IndexerStatus
+	Err      error
+}
+
+func (e *MoveError) Error() string {
+	return fmt.Sprintf(
+		"move on channel %d to %d failed at %d (status %s): %v",
+		e.Channel, e.Target, e.Position, e.Status, e.Err,
+	)
+}
+
+func (e *MoveError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Returns the interval used to poll the indexer while
+waiting for a move to finish
+*/
+func (o *OEM750x) pollInterval() time.Duration {
+	if o.PollInterval <= 0 {
+		return DefaultPollInterval
+	}
+	return o.PollInterval
+}
+
+/*
+Moves the motor to an absolute position in steps and blocks
+until the indexer is ready, verifying the final position.
+The motor is left in normal and absolute positioning mode
+*/
+func (o *OEM750x) MoveTo(ctx context.Context, channel uint, position int) error {
+	if err := o.SetNormalMode(channel); err != nil {
+		return err
+	} else if err := o.SetAbsoluteMode(channel); err != nil {
+		return err
+	} else if err := o.SetTargetDistance(channel, position); err != nil {
+		return err
+	}
+	return o.moveAndWait(ctx, channel, position)
+}
+
+/*
+Moves the motor by a number of steps relative to the current
+position and blocks until the indexer is ready, verifying the
+final position. The motor is left in normal and incremental
+positioning mode
+*/
+func (o *OEM750x) MoveBy(ctx context.Context, channel uint, steps int) error {
+	start, err := o.GetAbsolutePosition(channel)
+	if err != nil {
+		return err
+	}
+	if err := o.SetNormalMode(channel); err != nil {
+		return err
+	} else if err := o.SetIncrementalMode(channel); err != nil {
+		return err
+	} else if err := o.SetTargetDistance(channel, steps); err != nil {
+		return err
+	}
+	return o.moveAndWait(ctx, channel, start+steps)
+}
+
+/*
+Issues the go command, waits for the move to finish and
+compares the final absolute position with the target
+*/
+func (o *OEM750x) moveAndWait(ctx context.Context, channel uint, target int) error {
+	if err := o.Go(channel); err != nil {
+		return err
+	}
+	if err := o.WaitForMove(ctx, channel); err != nil {
+		var moveErr *MoveError
+		if errors.As(err, &moveErr) {
+			moveErr.Target = target
+		}
+		return err
+	}
+	position, err := o.GetAbsolutePosition(channel)
+	if err != nil {
+		return err
+	}
+	if position != target {
+		return &MoveError{
+			Channel:  channel,
+			Target:   target,
+			Position: position,
+			Status:   IndexerReady,
+			Err:      ErrPositionMismatch,
+		}
+	}
+	return nil
+}
+
+/*
+Blocks until the indexer leaves the busy state, polling the
+indexer status at the configured interval. Returns a MoveError
+if the attention flag is raised, if the move was terminated
+by a limit or if the context is done. The motor is stopped
+when the context is done or the attention is raised while
+it is still busy
+
+Note: the absolute position is only read once the indexer is
+ready, since PR is buffered and answers after the move ends
+*/
+func (o *OEM750x) WaitForMove(ctx context.Context, channel uint) error {
+	return o.waitForMove(ctx, channel, o.pollInterval())
+}
+
+func (o *OEM750x) waitForMove(ctx context.Context, channel uint, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := o.GetIndexerStatus(channel)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case IndexerReady:
+			return nil
+		case IndexerReadyAttention:
+			return o.attentionError(channel, status)
+		case IndexerBusyAttention:
+			if err := o.Stop(channel); err != nil {
+				return errors.Join(o.attentionError(channel, status), err)
+			}
+			return o.attentionError(channel, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			moveErr := &MoveError{Channel: channel, Status: status, Err: ctx.Err()}
+			if err := o.Stop(channel); err != nil {
+				return errors.Join(moveErr, err)
+			}
+			if position, err := o.stoppedPosition(channel); err == nil {
+				moveErr.Position = position
+			}
+			return moveErr
+		case <-ticker.C:
+		}
+	}
+}
+
+/*
+Builds the error reported when the indexer raises the
+attention flag, identifying whether a limit was reached
+*/
+func (o *OEM750x) attentionError(channel uint, status IndexerStatus) error {
+	moveErr := &MoveError{Channel: channel, Status: status, Err: ErrAttention}
+	limits, err := o.ReadLimitStatus(channel)
+	if err != nil {
+		return errors.Join(moveErr, err)
+	}
+	if limits.LastMoveStopped() {
+		moveErr.Err = ErrLimitReached
+	}
+	if status == IndexerReadyAttention {
+		if position, err := o.GetAbsolutePosition(channel); err == nil {
+			moveErr.Position = position
+		}
+	}
+	return moveErr
+}
+
+/*
+Waits for the motor stopped by S to decelerate and reads
+its absolute position, since PR is buffered and the drive
+only answers it after the move ends
+*/
+func (o *OEM750x) stoppedPosition(channel uint) (int, error) {
+	if err := o.waitStopped(channel); err != nil {
+		return 0, err
+	}
+	return o.GetAbsolutePosition(channel)
+}
+
+/*
+Number of times S is sent to stop a motor that is not
+watched by the caller before falling back to kill (K)
+*/
+const stopAttempts = 3
+
+/*
+Stops the motor and waits for it to decelerate, sending S
+up to stopAttempts times before falling back to kill (K),
+for the jogs and continuous moves left alone by the caller
+*/
+func (o *OEM750x) forceStop(channel uint) error {
+	var errs []error
+	for range stopAttempts {
+		err := o.Stop(channel)
+		if err == nil {
+			err = o.waitStopped(channel)
+		}
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if err := o.Kill(channel); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	return nil
+}
+
+/*
+Returns ErrBusy when the indexer of the channel is moving,
+since the buffered queries (e.g., PR, V and A) are only
+answered once the move is finished
+*/
+func (o *OEM750x) checkIdle(ctx context.Context, channel uint) error {
+	status, err := o.GetIndexerStatusContext(ctx, channel)
+	if err != nil {
+		return err
+	}
+	if status == IndexerBusy || status == IndexerBusyAttention {
+		return fmt.Errorf("channel %d: %w", channel, ErrBusy)
+	}
+	return nil
+}
+
+/*
+Polls the indexer status until the motor stopped by S is
+ready, for up to the timeout of the instance
+*/
+func (o *OEM750x) waitStopped(channel uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout())
+	defer cancel()
+	ticker := time.NewTicker(o.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		status, err := o.GetIndexerStatus(channel)
+		if err != nil {
+			return err
+		}
+		if status == IndexerReady || status == IndexerReadyAttention {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("channel %d still moving after stop: %w: %w", channel, ErrTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestMoveToAndMoveBy(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+	ctx := context.Background()
+
+	if err := parker.MoveTo(ctx, 1, 1500); err != nil {
+		t.Fatal(err)
+	}
+	if position := sim.Position(1); position != 1500 {
+		t.Fatalf("position after MoveTo = %d", position)
+	}
+	if err := parker.MoveBy(ctx, 1, -2500); err != nil {
+		t.Fatal(err)
+	}
+	if position := sim.Position(1); position != -1000 {
+		t.Fatalf("position after MoveBy = %d", position)
+	}
+}
+
+func TestMoveStopsAtLimit(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	sim.SetTravel(1, -1000, 800)
+	parker := newFastAxis(t, sim)
+
+	err := parker.MoveTo(context.Background(), 1, 2000)
+	if !errors.Is(err, protocol.ErrLimitReached) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	var moveErr *protocol.MoveError
+	if !errors.As(err, &moveErr) {
+		t.Fatalf("expected a MoveError, got %T", err)
+	}
+	if moveErr.Target != 2000 || moveErr.Position != 800 {
+		t.Fatalf("unexpected move error %+v", moveErr)
+	}
+}
+
+func TestMoveCancelledStopsMotor(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err := parker.MoveBy(ctx, 1, 1000000)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if sim.IsMoving(1) {
+		t.Fatal("motor still moving after cancellation")
+	}
+}
+
+func TestBufferedQueriesRejectedWhileBusy(t *testing.T) {
+	sim := simulator.New(simulator.Options{Addresses: []uint{1, 2}})
+	parker := newFastAxis(t, sim)
+
+	if err := parker.SetTargetVelocity(1, 1); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetTargetDistance(1, 100000); err != nil {
+		t.Fatal(err)
+	} else if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Stop(1)
+
+	if err := parker.SetSoftLimits(1, protocol.SoftLimits{Min: -200000, Max: 200000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(1); !errors.Is(err, protocol.ErrBusy) {
+		t.Fatalf("expected Go to report the busy indexer, got %v", err)
+	}
+	axis, err := protocol.NewAxis(parker, 1, protocol.AxisConfig{StepsPerRev: 200, Pitch: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := axis.Position(); !errors.Is(err, protocol.ErrBusy) {
+		t.Fatalf("expected Position to report the busy indexer, got %v", err)
+	}
+	targets := []protocol.AxisTarget{{Channel: 1, Position: 0}, {Channel: 2, Position: 100}}
+	if err := parker.MultiAxisMove(context.Background(), targets, 1, 10); !errors.Is(err, protocol.ErrBusy) {
+		t.Fatalf("expected MultiAxisMove to report the busy indexer, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := parker.StopContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error from StopContext, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+/*
+Absolute target position in steps of a channel in a
+coordinated move
+*/
+type AxisTarget struct {
+	Channel  uint
+	Position int
+}
+
+/*
+Moves several channels to their absolute targets so that
+they start and finish together, as a straight line in joint
+space. The channel with the longest move, in revolutions,
+runs at the given velocity (rps) and acceleration (rps²),
+and the velocity and acceleration of the others are scaled
+by their share of that distance, so every profile takes the
+same time. Every channel is loaded before a single go
+command is broadcast, then the call blocks until all of
+them are ready and verifies the final positions. The channels
+are waited for concurrently, and on the first error or when
+the context is done all of them are stopped. The velocity and
+acceleration of every channel are restored when the call
+returns, while the normal (MN) and absolute (MPA) modes stay set
+
+Note: the broadcast go is executed by every drive of the
+chain, so the drives that are not part of the move must not
+have a pending distance. The scaled values are rounded to
+the resolution of V and A, and are at least MinVelocity and
+MinAcceleration, so very short moves may finish earlier
+*/
+func (o *OEM750x) MultiAxisMove(ctx context.Context, targets []AxisTarget, velocity float64, acceleration float64) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("%w: multi-axis move requires at least one channel", ErrInvalidParameter)
+	}
+	if err := checkRange("velocity", velocity, MinVelocity, MaxVelocity); err != nil {
+		return err
+	}
+	if err := checkRange("acceleration", acceleration, MinAcceleration, MaxAcceleration); err != nil {
+		return err
+	}
+
+	revolutions := make([]float64, len(targets))
+	setpoints := make([]setpoint, len(targets))
+	longest := 0.0
+	included := make(map[uint]bool, len(targets))
+	for i, target := range targets {
+		if target.Channel == 0 || included[target.Channel] {
+			return fmt.Errorf("%w: channel %d in multi-axis move", ErrInvalidParameter, target.Channel)
+		}
+		included[target.Channel] = true
+		if err := checkRange("distance", target.Position, -MaxDistance, MaxDistance); err != nil {
+			return err
+		}
+		state := o.snapshotState(target.Channel)
+		if state.limits != nil && (target.Position < state.limits.Min || target.Position > state.limits.Max) {
+			return &SoftLimitError{Channel: target.Channel, Target: target.Position, Limits: *state.limits}
+		}
+		if err := o.checkIdle(ctx, target.Channel); err != nil {
+			return err
+		}
+		position, err := o.GetAbsolutePositionContext(ctx, target.Channel)
+		if err != nil {
+			return err
+		}
+		previousVelocity, previousAcceleration, resolution, err := o.motionParameters(target.Channel, state)
+		if err != nil {
+			return err
+		}
+		setpoints[i] = setpoint{previousVelocity, previousAcceleration}
+		revolutions[i] = math.Abs(float64(target.Position-position)) / float64(resolution)
+		longest = max(longest, revolutions[i])
+	}
+	if longest == 0 {
+		return nil
+	}
+
+	err := o.startAxes(ctx, targets, revolutions, longest, velocity, acceleration)
+	if err == nil {
+		err = o.waitForAxes(ctx, targets)
+	}
+	return errors.Join(err, o.restoreSetpoints(targets, setpoints))
+}
+
+/*
+Velocity and acceleration of a channel restored after a
+coordinated move
+*/
+type setpoint struct {
+	velocity     float64
+	acceleration float64
+}
+
+/*
+Loads the scaled profile and the target of every channel
+of a coordinated move and broadcasts the go command
+*/
+func (o *OEM750x) startAxes(ctx context.Context, targets []AxisTarget, revolutions []float64, longest float64, velocity float64, acceleration float64) error {
+	included := make(map[uint]bool, len(targets))
+	for i, target := range targets {
+		included[target.Channel] = true
+		ratio := revolutions[i] / longest
+		if err := o.SetNormalModeContext(ctx, target.Channel); err != nil {
+			return err
+		} else if err := o.SetAbsoluteModeContext(ctx, target.Channel); err != nil {
+			return err
+		} else if err := o.SetTargetVelocityContext(ctx, target.Channel, max(velocity*ratio, MinVelocity)); err != nil {
+			return err
+		} else if err := o.SetTargetAccelerationContext(ctx, target.Channel, max(acceleration*ratio, MinAcceleration)); err != nil {
+			return err
+		} else if err := o.SetTargetDistanceContext(ctx, target.Channel, target.Position); err != nil {
+			return err
+		}
+	}
+
+	var watches []*limitWatch
+	for _, channel := range o.stateChannels() {
+		if included[channel] {
+			continue
+		}
+		watch, err := o.checkSoftLimits(ctx, channel)
+		if err != nil {
+			return err
+		}
+		if watch != nil {
+			watches = append(watches, watch)
+		}
+	}
+	if err := o.WriteContext(ctx, "G"); err != nil {
+		return err
+	}
+	for _, watch := range watches {
+		o.startWatch(watch)
+	}
+	return nil
+}
+
+/*
+Sets back the velocity and acceleration of every channel
+of a coordinated move
+*/
+func (o *OEM750x) restoreSetpoints(targets []AxisTarget, setpoints []setpoint) error {
+	var errs []error
+	for i, target := range targets {
+		if err := o.SetTargetVelocity(target.Channel, setpoints[i].velocity); err != nil {
+			errs = append(errs, err)
+		} else if err := o.SetTargetAcceleration(target.Channel, setpoints[i].acceleration); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+/*
+Waits concurrently for every channel of a coordinated move,
+stopping all of them when any fails, and compares the final
+positions with the targets
+*/
+func (o *OEM750x) waitForAxes(ctx context.Context, targets []AxisTarget) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	failures := make([]error, len(targets))
+	var wait sync.WaitGroup
+	for i, target := range targets {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			if err := o.WaitForMove(ctx, target.Channel); err != nil {
+				var moveErr *MoveError
+				if errors.As(err, &moveErr) {
+					moveErr.Target = target.Position
+				}
+				failures[i] = err
+				cancel()
+			}
+		}()
+	}
+	wait.Wait()
+	if err := errors.Join(failures...); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, target := range targets {
+		position, err := o.GetAbsolutePosition(target.Channel)
+		if err != nil {
+			return err
+		}
+		if position != target.Position {
+			errs = append(errs, &MoveError{
+				Channel:  target.Channel,
+				Target:   target.Position,
+				Position: position,
+				Status:   IndexerReady,
+				Err:      ErrPositionMismatch,
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestMultiAxisMove(t *testing.T) {
+	sim := simulator.New(simulator.Options{Addresses: []uint{1, 2}})
+	parker := newFastAxis(t, sim)
+	if err := parker.SetResolution(2, 400); err != nil {
+		t.Fatal(err)
+	}
+
+	velocity, err := parker.GetTargetVelocity(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acceleration, err := parker.GetTargetAcceleration(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.MultiAxisMove(context.Background(), nil, 20, 200); !errors.Is(err, protocol.ErrInvalidParameter) {
+		t.Fatalf("expected an error for a move without channels, got %v", err)
+	}
+	targets := []protocol.AxisTarget{{Channel: 1, Position: 2000}, {Channel: 2, Position: -1000}}
+	if err := parker.MultiAxisMove(context.Background(), targets, 20, 200); err != nil {
+		t.Fatal(err)
+	}
+	if sim.Position(1) != 2000 || sim.Position(2) != -1000 {
+		t.Fatalf("positions after the move = %d, %d", sim.Position(1), sim.Position(2))
+	}
+	if restored, err := parker.GetTargetVelocity(2); err != nil || restored != velocity {
+		t.Fatalf("velocity of the shorter axis = %g, %v, expected %g", restored, err, velocity)
+	}
+	if restored, err := parker.GetTargetAcceleration(2); err != nil || restored != acceleration {
+		t.Fatalf("acceleration of the shorter axis = %g, %v, expected %g", restored, err, acceleration)
+	}
+	if restored, err := parker.GetTargetVelocity(1); err != nil || restored != 50 {
+		t.Fatalf("velocity of the longer axis = %g, %v", restored, err)
+	}
+
+	sim.SetTravel(2, -1200, 200)
+	targets = []protocol.AxisTarget{{Channel: 1, Position: 0}, {Channel: 2, Position: 1500}}
+	if err := parker.MultiAxisMove(context.Background(), targets, 20, 200); err == nil {
+		t.Fatal("expected the limit of the second axis to fail the move")
+	}
+	if sim.IsMoving(1) || sim.Position(1) == 0 {
+		t.Fatalf("expected the first axis to be stopped, position %d", sim.Position(1))
+	}
+	if restored, err := parker.GetTargetVelocity(1); err != nil || restored != 50 {
+		t.Fatalf("velocity after the failed move = %g, %v", restored, err)
+	}
+
+	parker.SetSoftLimits(2, protocol.SoftLimits{Min: -500, Max: 500})
+	position := sim.Position(1)
+	targets = []protocol.AxisTarget{{Channel: 1, Position: 0}, {Channel: 2, Position: -800}}
+	if err := parker.MultiAxisMove(context.Background(), targets, 20, 200); !errors.Is(err, protocol.ErrSoftLimit) {
+		t.Fatalf("expected a soft limit error, got %v", err)
+	}
+	if sim.IsMoving(1) || sim.Position(1) != position {
+		t.Fatal("no axis may move when a target is outside of the limits")
+	}
+}
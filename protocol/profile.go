@@ -0,0 +1,164 @@
+package protocol
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+/*
+Velocity profile of a normal move of D steps with the
+velocity V (rps), the acceleration A (rps²) and the motor
+resolution MR (steps/rev). The motor accelerates and
+decelerates at A, cruising at V when the distance allows
+it (trapezoid) or reversing at the peak velocity otherwise
+(triangle)
+*/
+type MotionProfile struct {
+	Steps        int
+	Velocity     float64
+	Acceleration float64
+	Resolution   uint
+	PeakVelocity float64
+	Triangular   bool
+	Accelerate   time.Duration
+	Cruise       time.Duration
+	Decelerate   time.Duration
+	Total        time.Duration
+}
+
+/*
+Point of a motion profile, with the position in steps from
+the start of the move and the velocity in rps
+*/
+type ProfileSample struct {
+	Time     time.Duration
+	Position float64
+	Velocity float64
+}
+
+/*
+Calculates the profile of a move of steps with the velocity
+(rps), acceleration (rps²) and resolution (steps/rev), in the
+ranges accepted by the setpoint commands
+*/
+func NewMotionProfile(steps int, velocity float64, acceleration float64, resolution uint) (MotionProfile, error) {
+	if err := checkRange("distance", steps, -MaxDistance, MaxDistance); err != nil {
+		return MotionProfile{}, err
+	} else if err := checkRange("velocity", velocity, MinVelocity, MaxVelocity); err != nil {
+		return MotionProfile{}, err
+	} else if err := checkRange("acceleration", acceleration, MinAcceleration, MaxAcceleration); err != nil {
+		return MotionProfile{}, err
+	} else if resolution == 0 {
+		return MotionProfile{}, fmt.Errorf("%w: resolution must be greater than zero", ErrInvalidParameter)
+	}
+
+	p := MotionProfile{
+		Steps:        steps,
+		Velocity:     velocity,
+		Acceleration: acceleration,
+		Resolution:   resolution,
+	}
+	revolutions := math.Abs(float64(steps)) / float64(resolution)
+	if revolutions == 0 {
+		return p, nil
+	}
+	ramp := velocity / acceleration
+	cruise := 0.0
+	p.PeakVelocity = velocity
+	if revolutions < velocity*ramp {
+		p.Triangular = true
+		p.PeakVelocity = math.Sqrt(revolutions * acceleration)
+		ramp = p.PeakVelocity / acceleration
+	} else {
+		cruise = (revolutions - velocity*ramp) / velocity
+	}
+	p.Accelerate = seconds(ramp)
+	p.Cruise = seconds(cruise)
+	p.Decelerate = seconds(ramp)
+	p.Total = seconds(2*ramp + cruise)
+	return p, nil
+}
+
+/*
+Returns the position in steps from the start of the move
+and the velocity in rps at the elapsed time, signed as the
+distance of the move
+*/
+func (p MotionProfile) At(elapsed time.Duration) (float64, float64) {
+	sign := 1.0
+	if p.Steps < 0 {
+		sign = -1
+	}
+	t := elapsed.Seconds()
+	ta, tc := p.Accelerate.Seconds(), p.Cruise.Seconds()
+	var revolutions, velocity float64
+	switch {
+	case t <= 0:
+		return 0, 0
+	case elapsed >= p.Total:
+		return float64(p.Steps), 0
+	case t < ta:
+		velocity = p.Acceleration * t
+		revolutions = velocity * t / 2
+	case t < ta+tc:
+		velocity = p.PeakVelocity
+		revolutions = p.PeakVelocity*ta/2 + p.PeakVelocity*(t-ta)
+	default:
+		remaining := p.Total.Seconds() - t
+		velocity = p.Acceleration * remaining
+		total := math.Abs(float64(p.Steps)) / float64(p.Resolution)
+		revolutions = total - velocity*remaining/2
+	}
+	return sign * revolutions * float64(p.Resolution), sign * velocity
+}
+
+/*
+Samples the position and velocity of the profile every
+interval, from the start to the end of the move included
+*/
+func (p MotionProfile) Sample(interval time.Duration) []ProfileSample {
+	if interval <= 0 {
+		return nil
+	}
+	var samples []ProfileSample
+	for elapsed := time.Duration(0); ; elapsed += interval {
+		if elapsed > p.Total {
+			elapsed = p.Total
+		}
+		position, velocity := p.At(elapsed)
+		samples = append(samples, ProfileSample{Time: elapsed, Position: position, Velocity: velocity})
+		if elapsed == p.Total {
+			return samples
+		}
+	}
+}
+
+/*
+Calculates the profile of a normal move of the channel
+with the velocity, acceleration and resolution set through
+this instance, reading from the drive the ones that were not
+*/
+func (o *OEM750x) PlanMove(channel uint, steps int) (MotionProfile, error) {
+	velocity, acceleration, resolution, err := o.motionParameters(channel, o.snapshotState(channel))
+	if err != nil {
+		return MotionProfile{}, err
+	}
+	return NewMotionProfile(steps, velocity, acceleration, resolution)
+}
+
+/*
+Returns the time a normal move of steps takes on the
+channel, as calculated by PlanMove
+*/
+func (o *OEM750x) EstimateMoveDuration(channel uint, steps int) (time.Duration, error) {
+	profile, err := o.PlanMove(channel, steps)
+	if err != nil {
+		return 0, err
+	}
+	return profile.Total, nil
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(math.Round(value * float64(time.Second)))
+}
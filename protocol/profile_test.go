@@ -0,0 +1,94 @@
+package protocol_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	return c.now
+}
+
+func (c *stepClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestMotionProfile(t *testing.T) {
+	trapezoid, err := protocol.NewMotionProfile(5000, 2, 10, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trapezoid.Triangular || trapezoid.PeakVelocity != 2 || trapezoid.Accelerate != 200*time.Millisecond ||
+		trapezoid.Cruise != 2300*time.Millisecond || trapezoid.Total != 2700*time.Millisecond {
+		t.Fatalf("unexpected trapezoid %+v", trapezoid)
+	}
+	if position, velocity := trapezoid.At(1350 * time.Millisecond); math.Abs(position-2500) > 1e-6 || velocity != 2 {
+		t.Fatalf("trapezoid midpoint at %g steps, %g rps", position, velocity)
+	}
+
+	triangle, err := protocol.NewMotionProfile(-100, 2, 10, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !triangle.Triangular || triangle.PeakVelocity != 1 || triangle.Total != 200*time.Millisecond {
+		t.Fatalf("unexpected triangle %+v", triangle)
+	}
+	samples := triangle.Sample(30 * time.Millisecond)
+	last := samples[len(samples)-1]
+	if len(samples) != 8 || last.Time != triangle.Total || last.Position != -100 || last.Velocity != 0 {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Position > samples[i-1].Position || samples[i].Velocity > 0 {
+			t.Fatalf("samples are not monotonic: %+v", samples)
+		}
+	}
+	if math.Abs(samples[3].Velocity+0.9) > 1e-9 {
+		t.Fatalf("velocity at %s = %g", samples[3].Time, samples[3].Velocity)
+	}
+
+	if _, err := protocol.NewMotionProfile(100, 60, 10, 1000); !errors.Is(err, protocol.ErrOutOfRange) {
+		t.Fatalf("velocity 60 returned %v", err)
+	}
+}
+
+func TestEstimateMoveDuration(t *testing.T) {
+	clock := &stepClock{now: time.Unix(0, 0)}
+	sim := simulator.New(simulator.Options{Clock: clock.Now})
+	parker := newFastAxis(t, sim)
+	if err := parker.SetTargetVelocity(1, 5); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetTargetAcceleration(1, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	duration, err := parker.EstimateMoveDuration(1, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != 2250*time.Millisecond {
+		t.Fatalf("estimated duration = %s", duration)
+	}
+	if err := parker.SetTargetDistance(1, 2000); err != nil {
+		t.Fatal(err)
+	} else if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(duration - time.Millisecond)
+	if !sim.IsMoving(1) {
+		t.Fatalf("move finished before the estimate at %d", sim.Position(1))
+	}
+	clock.Advance(time.Millisecond)
+	if sim.IsMoving(1) || sim.Position(1) != 2000 {
+		t.Fatalf("move not finished at the estimate, position %d", sim.Position(1))
+	}
+}
@@ -1,10 +1,12 @@
 package protocol
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/devicehub-go/unicomm"
 )
@@ -13,9 +15,23 @@ const (
 	CR string = "\r"
 )
 
+/*
+Time given to an exchange with the drive when the
+OEM750x Timeout is zero
+*/
+const DefaultTimeout = time.Second
+
 type OEM750x struct {
 	Communication unicomm.Unicomm
-	mutex         sync.Mutex
+	PollInterval  time.Duration
+	Timeout       time.Duration
+	Retry         RetryPolicy
+	JogDeadman    time.Duration
+	bus           chan struct{}
+	busOnce       sync.Once
+	states        map[uint]*channelState
+	jogs          map[uint]*jog
+	stateMutex    sync.Mutex
 }
 
 /*
@@ -40,29 +56,178 @@ func (o *OEM750x) IsConnected() bool {
 }
 
 /*
-Writes a message to the device
+Returns the timeout of a single exchange with the drive
 */
-func (o *OEM750x) Write(message string) error {
+func (o *OEM750x) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return o.Timeout
+}
+
+/*
+Acquires the exclusive use of the communication, giving
+up when the context is done
+*/
+func (o *OEM750x) lock(ctx context.Context) error {
+	o.busOnce.Do(func() {
+		o.bus = make(chan struct{}, 1)
+	})
+	select {
+	case o.bus <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Releases the communication acquired with lock
+*/
+func (o *OEM750x) unlock() {
+	<-o.bus
+}
+
+/*
+Discards the bytes pending on the input, such as a late
+echo or response of an exchange that timed out
+*/
+func (o *OEM750x) drain() {
+	for range 64 {
+		pending, err := o.Communication.Read(256)
+		if err != nil || len(pending) == 0 {
+			return
+		}
+	}
+}
+
+/*
+Runs a transfer with exclusive use of the communication,
+bounded by the context and the timeout of the instance.
+When the context is done first, the communication stays
+locked until the pending transfer returns and the input
+is drained, so the next exchange is not misaligned
+*/
+func (o *OEM750x) exchange(ctx context.Context, message string, transfer func() ([]byte, error)) ([]byte, error) {
 	if !o.IsConnected() {
-		return fmt.Errorf("device not connected")
+		return nil, ErrNotConnected
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeout())
+	defer cancel()
+	if err := o.lock(ctx); err != nil {
+		return nil, fmt.Errorf("%s: waiting for the communication: %w", message, err)
 	}
-	o.mutex.Lock()
-	defer o.mutex.Unlock()
 
+	type result struct {
+		response []byte
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := transfer()
+		if err != nil {
+			o.drain()
+		}
+		done <- result{response, err}
+	}()
+
+	select {
+	case r := <-done:
+		o.unlock()
+		return r.response, transportError(r.err)
+	case <-ctx.Done():
+		go func() {
+			<-done
+			o.drain()
+			o.unlock()
+		}()
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s: %w: %w", message, ErrTimeout, ctx.Err())
+		}
+		return nil, fmt.Errorf("%s: %w", message, ctx.Err())
+	}
+}
+
+/*
+Sends the message and checks the echo of the device. In
+error checking mode the echo is read by length, since a
+drive that ignores the command echoes an & for each byte,
+including the CR. The communication must be locked by
+the caller
+*/
+func (o *OEM750x) send(message string) error {
 	err := o.Communication.Write([]byte(message + CR))
 	if err != nil {
 		return err
 	}
-	echo, err := o.Communication.ReadUntil(CR)
+	var echo []byte
+	if o.checksErrors(messageAddress(message)) {
+		echo, err = o.readCount(len(message) + len(CR))
+	} else {
+		echo, err = o.Communication.ReadUntil(CR)
+	}
+	if ignoredEcho(echo) {
+		o.drain()
+		return o.communicationError(message)
+	}
 	if err != nil {
 		return err
 	}
 	if string(echo) != message+CR {
-		return fmt.Errorf("unexpected response: %s", string(echo))
+		return &EchoMismatchError{Sent: message, Received: string(echo)}
 	}
 	return nil
 }
 
+/*
+Reads the given number of bytes, failing when the
+communication stops sending before
+*/
+func (o *OEM750x) readCount(size int) ([]byte, error) {
+	data := make([]byte, 0, size)
+	for len(data) < size {
+		chunk, err := o.Communication.Read(uint(size - len(data)))
+		if err != nil {
+			return data, err
+		}
+		if len(chunk) == 0 {
+			return data, fmt.Errorf("%w: read %d of %d bytes", ErrTimeout, len(data), size)
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
+
+/*
+Writes a message to the device
+*/
+func (o *OEM750x) Write(message string) error {
+	return o.WriteContext(context.Background(), message)
+}
+
+/*
+Writes a message to the device, giving up when the context
+is done or the timeout of the instance elapses. Idempotent
+commands are retried on echo mismatch as set by Retry
+*/
+func (o *OEM750x) WriteContext(ctx context.Context, message string) error {
+	_, err := o.retry(ctx, message, func() ([]byte, error) {
+		return nil, o.send(message)
+	})
+	return err
+}
+
+/*
+Writes a message to the device without retrying it, for
+commands whose effect depends on the previous ones
+*/
+func (o *OEM750x) writeOnce(message string) error {
+	_, err := o.exchange(context.Background(), message, func() ([]byte, error) {
+		return nil, o.send(message)
+	})
+	return err
+}
+
 /*
 Cleans the response byte array to prevent characters
 as CR and NULL from being present
@@ -82,28 +247,26 @@ Sends a command to the device and returns
 the response
 */
 func (o *OEM750x) Request(message string) ([]byte, error) {
-	if !o.IsConnected() {
-		return nil, fmt.Errorf("device not connected")
-	}
-	o.mutex.Lock()
-	defer o.mutex.Unlock()
+	return o.RequestContext(context.Background(), message)
+}
 
-	err := o.Communication.Write([]byte(message + CR))
-	if err != nil {
-		return nil, err
-	}
-	echo, err := o.Communication.ReadUntil(CR)
-	if err != nil {
-		return nil, err
-	}
-	if string(echo) != message+CR {
-		return nil, fmt.Errorf("unexpected response: %s", string(echo))
-	}
-	response, err := o.Communication.ReadUntil(CR)
-	if err != nil {
-		return nil, err
-	}
-	return cleanResponse(response), nil
+/*
+Sends a command to the device and returns the response,
+giving up when the context is done or the timeout of
+the instance elapses. Idempotent commands are retried on
+echo mismatch as set by Retry
+*/
+func (o *OEM750x) RequestContext(ctx context.Context, message string) ([]byte, error) {
+	return o.retry(ctx, message, func() ([]byte, error) {
+		if err := o.send(message); err != nil {
+			return nil, err
+		}
+		response, err := o.Communication.ReadUntil(CR)
+		if err != nil {
+			return nil, err
+		}
+		return cleanResponse(response), nil
+	})
 }
 
 /*
@@ -114,7 +277,7 @@ func ParseValueResponse(response []byte) (string, error) {
 	responseStr := string(response)
 	matches := expected.FindStringSubmatch(responseStr)
 	if len(matches) != 2 {
-		return "", fmt.Errorf("invalid response format: %s", responseStr)
+		return "", &ParseError{Expected: "value", Response: responseStr}
 	}
 	return matches[1], nil
 }
@@ -123,7 +286,14 @@ func ParseValueResponse(response []byte) (string, error) {
 Request a string value from the device
 */
 func (o *OEM750x) RequestString(message string, parse bool) (string, error) {
-	response, err := o.Request(message)
+	return o.RequestStringContext(context.Background(), message, parse)
+}
+
+/*
+Request a string value from the device with a context
+*/
+func (o *OEM750x) RequestStringContext(ctx context.Context, message string, parse bool) (string, error) {
+	response, err := o.RequestContext(ctx, message)
 	if err != nil {
 		return "", err
 	}
@@ -137,7 +307,14 @@ func (o *OEM750x) RequestString(message string, parse bool) (string, error) {
 Request an integer value from the device
 */
 func (o *OEM750x) RequestInt(message string) (int, error) {
-	response, err := o.Request(message)
+	return o.RequestIntContext(context.Background(), message)
+}
+
+/*
+Request an integer value from the device with a context
+*/
+func (o *OEM750x) RequestIntContext(ctx context.Context, message string) (int, error) {
+	response, err := o.RequestContext(ctx, message)
 	if err != nil {
 		return 0, err
 	}
@@ -145,14 +322,25 @@ func (o *OEM750x) RequestInt(message string) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	return strconv.Atoi(valueStr)
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, &ParseError{Expected: "integer", Response: string(response)}
+	}
+	return value, nil
 }
 
 /*
 Request a float64 value from the device
 */
 func (o *OEM750x) RequestFloat(message string) (float64, error) {
-	response, err := o.Request(message)
+	return o.RequestFloatContext(context.Background(), message)
+}
+
+/*
+Request a float64 value from the device with a context
+*/
+func (o *OEM750x) RequestFloatContext(ctx context.Context, message string) (float64, error) {
+	response, err := o.RequestContext(ctx, message)
 	if err != nil {
 		return 0, err
 	}
@@ -160,5 +348,9 @@ func (o *OEM750x) RequestFloat(message string) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
-	return strconv.ParseFloat(valueStr, 64)
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return 0, &ParseError{Expected: "number", Response: string(response)}
+	}
+	return value, nil
 }
@@ -0,0 +1,94 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+)
+
+/*
+Transport whose first read blocks until it is released and
+then fails, leaving the late echo and response on the input
+*/
+type stalledTransport struct {
+	mutex   sync.Mutex
+	pending []byte
+	release chan struct{}
+	stalled bool
+}
+
+func (s *stalledTransport) Connect() error    { return nil }
+func (s *stalledTransport) Disconnect() error { return nil }
+func (s *stalledTransport) IsConnected() bool { return true }
+
+func (s *stalledTransport) Read(size uint) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	n := min(int(size), len(s.pending))
+	data := s.pending[:n]
+	s.pending = s.pending[n:]
+	return data, nil
+}
+
+func (s *stalledTransport) ReadUntil(delimiter string) ([]byte, error) {
+	s.mutex.Lock()
+	stalled := s.stalled
+	s.stalled = false
+	s.mutex.Unlock()
+	if stalled {
+		<-s.release
+		return nil, errors.New("read until timeout")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	index := strings.Index(string(s.pending), delimiter)
+	if index < 0 {
+		return nil, errors.New("read until timeout")
+	}
+	data := s.pending[:index+len(delimiter)]
+	s.pending = s.pending[index+len(delimiter):]
+	return data, nil
+}
+
+func (s *stalledTransport) Write(message []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending = append(s.pending, message...)
+	s.pending = append(s.pending, "*R\r"...)
+	return nil
+}
+
+func TestRequestTimeoutDrainsInput(t *testing.T) {
+	transport := &stalledTransport{release: make(chan struct{}), stalled: true}
+	parker := &protocol.OEM750x{Communication: transport, Timeout: 20 * time.Millisecond}
+
+	start := time.Now()
+	if _, err := parker.Request("1R"); !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, protocol.ErrTimeout) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("request returned after %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := parker.RequestContext(ctx, "1R"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error while the bus is busy, got %v", err)
+	}
+	if err := parker.SetTargetVelocityContext(ctx, 1, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error from the setter, got %v", err)
+	}
+
+	close(transport.release)
+	parker.Timeout = time.Second
+	status, err := parker.GetIndexerStatus(1)
+	if err != nil || status != protocol.IndexerReady {
+		t.Fatalf("status after drain = %q, %v", status, err)
+	}
+}
@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -19,8 +20,15 @@ const (
 Gets the software part number and its revision level
 */
 func (o *OEM750x) GetPartNumber(channel uint) (string, error) {
+	return o.GetPartNumberContext(context.Background(), channel)
+}
+
+/*
+Same as GetPartNumber, giving up when the context is done
+*/
+func (o *OEM750x) GetPartNumberContext(ctx context.Context, channel uint) (string, error) {
 	msg := fmt.Sprintf("%dRV", channel)
-	return o.RequestString(msg, false)
+	return o.RequestStringContext(ctx, msg, false)
 }
 
 /*
@@ -34,8 +42,15 @@ limit end reached, unsuccessful sequence or memory
 checksum error
 */
 func (o *OEM750x) GetIndexerStatus(channel uint) (IndexerStatus, error) {
+	return o.GetIndexerStatusContext(context.Background(), channel)
+}
+
+/*
+Same as GetIndexerStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetIndexerStatusContext(ctx context.Context, channel uint) (IndexerStatus, error) {
 	msg := fmt.Sprintf("%dR", channel)
-	response, err := o.RequestString(msg, true)
+	response, err := o.RequestStringContext(ctx, msg, true)
 	if err != nil {
 		return "", err
 	}
@@ -43,47 +58,64 @@ func (o *OEM750x) GetIndexerStatus(channel uint) (IndexerStatus, error) {
 }
 
 /*
-Gets the closed loop status
-
-The response is a 2-character string where:
-  - The first indicates static position loss
-  - The second indicates post move position loss
-  - The third indicates homing function failure
-  - The forth indicates a stall
+Gets the closed loop status as the 4-character string of
+ClosedLoopStatus.String. See ReadClosedLoopStatus
 */
 func (o *OEM750x) GetClosedLoopStatus(channel uint) (string, error) {
-	msg := fmt.Sprintf("%dRA", channel)
-	response, err := o.RequestString(msg, true)
+	return o.GetClosedLoopStatusContext(context.Background(), channel)
+}
+
+/*
+Same as GetClosedLoopStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetClosedLoopStatusContext(ctx context.Context, channel uint) (string, error) {
+	status, err := o.ReadClosedLoopStatusContext(ctx, channel)
 	if err != nil {
 		return "", err
 	}
-	switch response {
-	case "@":
-		return "0000", nil
-	case "A":
-		return "1000", nil
-	case "B":
-		return "0100", nil
-	case "D":
-		return "0010", nil
-	case "E":
-		return "1010", nil
-	case "F":
-		return "0110", nil
-	case "H":
-		return "0001", nil
-	case "I":
-		return "1001", nil
-	case "J":
-		return "0101", nil
-	case "L":
-		return "0011", nil
-	case "M":
-		return "1011", nil
-	case "N":
-		return "0111", nil
+	return status.String(), nil
+}
+
+/*
+Reads the closed loop status reported by RC, indicating static
+and post move position losses, homing failure and stall. The
+manual assigns these flags to RC, while RB reports loops,
+pauses, shutdown and triggers. RC is buffered, so the drive
+only answers it once the current move ends
+*/
+func (o *OEM750x) ReadClosedLoopStatus(channel uint) (ClosedLoopStatus, error) {
+	return o.ReadClosedLoopStatusContext(context.Background(), channel)
+}
+
+/*
+Same as ReadClosedLoopStatus, giving up when the context is done
+*/
+func (o *OEM750x) ReadClosedLoopStatusContext(ctx context.Context, channel uint) (ClosedLoopStatus, error) {
+	msg := fmt.Sprintf("%dRC", channel)
+	response, err := o.RequestStringContext(ctx, msg, true)
+	if err != nil {
+		return ClosedLoopStatus{}, err
 	}
-	return "", fmt.Errorf("invalid closed loop status response: %s", response)
+	return ParseClosedLoopStatus(response)
+}
+
+/*
+Gets the loop, pause, shutdown and trigger status reported by RB
+*/
+func (o *OEM750x) GetExecutionStatus(channel uint) (ExecutionStatus, error) {
+	return o.GetExecutionStatusContext(context.Background(), channel)
+}
+
+/*
+Same as GetExecutionStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetExecutionStatusContext(ctx context.Context, channel uint) (ExecutionStatus, error) {
+	msg := fmt.Sprintf("%dRB", channel)
+	response, err := o.RequestStringContext(ctx, msg, true)
+	if err != nil {
+		return ExecutionStatus{}, err
+	}
+	return ParseExecutionStatus(response)
 }
 
 /*
@@ -94,46 +126,91 @@ The response is a 4-character string where:
   - The last two characters represent the current condition of CW and CCW limits.
 */
 func (o *OEM750x) GetLimitsStatus(channel uint) (string, error) {
-	msg := fmt.Sprintf("%dRA", channel)
-	response, err := o.RequestString(msg, true)
+	return o.GetLimitsStatusContext(context.Background(), channel)
+}
+
+/*
+Same as GetLimitsStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetLimitsStatusContext(ctx context.Context, channel uint) (string, error) {
+	status, err := o.ReadLimitStatusContext(ctx, channel)
 	if err != nil {
 		return "", err
 	}
-	switch response {
-	case "@":
-		return "0000", nil
-	case "A":
-		return "1000", nil
-	case "B":
-		return "0100", nil
-	case "D":
-		return "0010", nil
-	case "E":
-		return "1010", nil
-	case "F":
-		return "0110", nil
-	case "H":
-		return "0001", nil
-	case "I":
-		return "1001", nil
-	case "J":
-		return "0101", nil
-	case "L":
-		return "0011", nil
-	case "M":
-		return "1011", nil
-	case "N":
-		return "0111", nil
+	return status.String(), nil
+}
+
+/*
+Reads the status of the end-of-travel limits reported by RA
+*/
+func (o *OEM750x) ReadLimitStatus(channel uint) (LimitStatus, error) {
+	return o.ReadLimitStatusContext(context.Background(), channel)
+}
+
+/*
+Same as ReadLimitStatus, giving up when the context is done
+*/
+func (o *OEM750x) ReadLimitStatusContext(ctx context.Context, channel uint) (LimitStatus, error) {
+	msg := fmt.Sprintf("%dRA", channel)
+	response, err := o.RequestStringContext(ctx, msg, true)
+	if err != nil {
+		return LimitStatus{}, err
+	}
+	return ParseLimitStatus(response)
+}
+
+/*
+Gets the hardware status of all inputs reported by IS. The
+OEM750X has no RS report, IS is its input status report
+*/
+func (o *OEM750x) GetInputStatus(channel uint) (InputStatus, error) {
+	return o.GetInputStatusContext(context.Background(), channel)
+}
+
+/*
+Same as GetInputStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetInputStatusContext(ctx context.Context, channel uint) (InputStatus, error) {
+	msg := fmt.Sprintf("%dIS", channel)
+	response, err := o.RequestStringContext(ctx, msg, false)
+	if err != nil {
+		return InputStatus{}, err
 	}
-	return "", fmt.Errorf("invalid limits status response: %s", response)
+	return ParseInputStatus(strings.TrimPrefix(response, "*"))
+}
+
+/*
+Gets the state of the trigger inputs reported by TS
+*/
+func (o *OEM750x) GetTriggerStatus(channel uint) (TriggerStatus, error) {
+	return o.GetTriggerStatusContext(context.Background(), channel)
+}
+
+/*
+Same as GetTriggerStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetTriggerStatusContext(ctx context.Context, channel uint) (TriggerStatus, error) {
+	msg := fmt.Sprintf("%dTS", channel)
+	response, err := o.RequestStringContext(ctx, msg, false)
+	if err != nil {
+		return TriggerStatus{}, err
+	}
+	return ParseTriggerStatus(strings.TrimPrefix(response, "*"))
 }
 
 /*
 Gets the absolute position of the motor in steps
 */
 func (o *OEM750x) GetAbsolutePosition(channel uint) (int, error) {
+	return o.GetAbsolutePositionContext(context.Background(), channel)
+}
+
+/*
+Same as GetAbsolutePosition, giving up when the context is done
+*/
+func (o *OEM750x) GetAbsolutePositionContext(ctx context.Context, channel uint) (int, error) {
 	msg := fmt.Sprintf("%dPR", channel)
-	return o.RequestInt(msg)
+	return o.RequestIntContext(ctx, msg)
 }
 
 /*
@@ -141,15 +218,22 @@ Gets an immediate position relative to start of the current
 move in steps
 */
 func (o *OEM750x) GetRelativePosition(channel uint) (int, error) {
+	return o.GetRelativePositionContext(context.Background(), channel)
+}
+
+/*
+Same as GetRelativePosition, giving up when the context is done
+*/
+func (o *OEM750x) GetRelativePositionContext(ctx context.Context, channel uint) (int, error) {
 	msg := fmt.Sprintf("%dW3", channel)
-	response, err := o.RequestString(msg, false)
+	response, err := o.RequestStringContext(ctx, msg, false)
 	if err != nil {
 		return 0, err
 	}
 	response = strings.TrimPrefix(response, "*")
 	value, err := strconv.ParseUint(response, 16, 64)
 	if err != nil {
-		return 0, err
+		return 0, &ParseError{Expected: "relative position", Response: response}
 	}
 	return int(int32(uint32(value))), nil
 }
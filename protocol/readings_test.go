@@ -0,0 +1,75 @@
+package protocol_test
+
+import (
+	"testing"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+)
+
+func TestParseLimitStatus(t *testing.T) {
+	tests := []struct {
+		response string
+		expected string
+	}{
+		{"@", "0000"}, {"A", "1000"}, {"B", "0100"}, {"C", "1100"},
+		{"D", "0010"}, {"E", "1010"}, {"F", "0110"}, {"G", "1110"},
+		{"H", "0001"}, {"I", "1001"}, {"J", "0101"}, {"K", "1101"},
+		{"L", "0011"}, {"M", "1011"}, {"N", "0111"}, {"O", "1111"},
+	}
+	for _, test := range tests {
+		status, err := protocol.ParseLimitStatus(test.response)
+		if err != nil {
+			t.Fatalf("%s: %v", test.response, err)
+		}
+		if status.String() != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.response, status, test.expected)
+		}
+	}
+
+	status, _ := protocol.ParseLimitStatus("M")
+	expected := protocol.LimitStatus{LastMoveStoppedByCW: true, CWActive: true, CCWActive: true}
+	if status != expected {
+		t.Errorf("M: got %+v", status)
+	}
+	for _, invalid := range []string{"", "P", "?", "AB"} {
+		if _, err := protocol.ParseLimitStatus(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestParseClosedLoopStatus(t *testing.T) {
+	tests := []struct {
+		response string
+		expected protocol.ClosedLoopStatus
+	}{
+		{"@", protocol.ClosedLoopStatus{}},
+		{"A", protocol.ClosedLoopStatus{Stall: true}},
+		{"B", protocol.ClosedLoopStatus{HomingFailed: true}},
+		{"C", protocol.ClosedLoopStatus{Stall: true, HomingFailed: true}},
+		{"D", protocol.ClosedLoopStatus{PostMovePositionLoss: true}},
+		{"H", protocol.ClosedLoopStatus{StaticPositionLoss: true}},
+	}
+	for _, test := range tests {
+		status, err := protocol.ParseClosedLoopStatus(test.response)
+		if err != nil {
+			t.Fatalf("%s: %v", test.response, err)
+		}
+		if status != test.expected {
+			t.Errorf("%s: got %+v, expected %+v", test.response, status, test.expected)
+		}
+	}
+
+	status, err := protocol.ParseClosedLoopStatus("K")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := protocol.ClosedLoopStatus{
+		StaticPositionLoss: true,
+		HomingFailed:       true,
+		Stall:              true,
+	}
+	if status != expected || status.String() != "1101" {
+		t.Errorf("K: got %+v", status)
+	}
+}
@@ -0,0 +1,81 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+/*
+Recovery policy applied when the echo of a command does not
+match the message sent. The pending input is always drained,
+then idempotent commands are sent again up to Retries times,
+optionally after resetting the communication of the chain
+with %
+*/
+type RetryPolicy struct {
+	Retries int
+	Resync  bool
+}
+
+/*
+Returns true if sending the command twice has the same
+effect as sending it once. Motion commands (G, GH, XR),
+the direction toggle (H) and the sequence definition
+delimiters (XD, XT) are never retried
+*/
+func Idempotent(message string) bool {
+	body := strings.TrimLeft(message, "0123456789")
+	switch {
+	case strings.HasPrefix(body, "G"):
+		return false
+	case strings.HasPrefix(body, "XR") && !strings.HasPrefix(body, "XRP"):
+		return false
+	case strings.HasPrefix(body, "XD"), strings.HasPrefix(body, "XT"):
+		return false
+	case body == "H":
+		return false
+	}
+	return true
+}
+
+/*
+Runs the exchange, retrying it according to the policy of
+the instance while the echo does not match and the command
+is idempotent. Commands ignored by a drive after a
+communication error are retried whatever the command,
+since the drive did not execute them
+*/
+func (o *OEM750x) retry(ctx context.Context, message string, transfer func() ([]byte, error)) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := o.exchange(ctx, message, transfer)
+		if err == nil || attempt >= o.Retry.Retries || ctx.Err() != nil {
+			return response, err
+		}
+		var ignored *CommunicationError
+		var mismatch *EchoMismatchError
+		switch {
+		case errors.As(err, &ignored):
+		case errors.As(err, &mismatch) && Idempotent(message):
+			if o.Retry.Resync {
+				o.resync(ctx)
+			}
+		default:
+			return response, err
+		}
+	}
+}
+
+/*
+Resets the communication of the chain with %, which takes
+no address, ignoring the response
+*/
+func (o *OEM750x) resync(ctx context.Context) {
+	reset := "%"
+	o.exchange(ctx, reset, func() ([]byte, error) {
+		if err := o.send(reset); err != nil {
+			return nil, err
+		}
+		return o.Communication.ReadUntil(CR)
+	})
+}
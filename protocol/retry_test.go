@@ -0,0 +1,87 @@
+package protocol_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+	"github.com/devicehub-go/unicomm"
+)
+
+/*
+Transport that garbles the given commands on the line a
+number of times, so the drive echoes the garbled command
+*/
+type noisyTransport struct {
+	unicomm.Unicomm
+	garble map[string]int
+	sent   []string
+}
+
+func (n *noisyTransport) Write(message []byte) error {
+	command := strings.TrimSuffix(string(message), protocol.CR)
+	n.sent = append(n.sent, command)
+	if n.garble[command] > 0 {
+		n.garble[command]--
+		message = []byte("?" + command + protocol.CR)
+	}
+	return n.Unicomm.Write(message)
+}
+
+func (n *noisyTransport) count(command string) int {
+	count := 0
+	for _, sent := range n.sent {
+		if sent == command {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRetryOnEchoMismatch(t *testing.T) {
+	transport := &noisyTransport{Unicomm: simulator.New(simulator.Options{}), garble: map[string]int{}}
+	parker := oem750x.NewWithCommunication(transport)
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	transport.garble["1V2.00"] = 1
+	var mismatch *protocol.EchoMismatchError
+	if err := parker.SetTargetVelocity(1, 2); !errors.As(err, &mismatch) ||
+		mismatch.Sent != "1V2.00" || mismatch.Received != "?1V2.00\r" {
+		t.Fatalf("expected an echo mismatch without retries, got %v", err)
+	}
+
+	parker.Retry = protocol.RetryPolicy{Retries: 2}
+	transport.garble["1V2.00"] = 1
+	if err := parker.SetTargetVelocity(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if velocity, err := parker.GetTargetVelocity(1); err != nil || velocity != 2 {
+		t.Fatalf("velocity = %v, %v", velocity, err)
+	}
+
+	transport.garble["1R"] = 2
+	if status, err := parker.GetIndexerStatus(1); err != nil || status != protocol.IndexerReady {
+		t.Fatalf("status = %q, %v", status, err)
+	}
+
+	transport.garble["1G"] = 1
+	if err := parker.Go(1); !errors.As(err, &mismatch) || transport.count("1G") != 1 {
+		t.Fatalf("go must not be retried, got %v after %d attempts", err, transport.count("1G"))
+	}
+
+	parker.Retry = protocol.RetryPolicy{Retries: 1, Resync: true}
+	transport.garble["1A5.00"] = 2
+	transport.sent = nil
+	if err := parker.SetTargetAcceleration(1, 5); !errors.As(err, &mismatch) {
+		t.Fatalf("expected an echo mismatch after the retries, got %v", err)
+	}
+	if strings.Join(transport.sent, " ") != "1A5.00 % 1A5.00" {
+		t.Fatalf("sent %q", transport.sent)
+	}
+}
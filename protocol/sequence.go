@@ -0,0 +1,548 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	MinSequence       uint    = 1
+	MaxSequence       uint    = 7
+	MaxSequenceLength int     = 255
+	MinDelay          float64 = 0.01
+	MaxDelay          float64 = 99999.99
+)
+
+/*
+Sequence of buffered commands that can be stored in the
+nonvolatile memory of the indexer. The builder methods
+validate each command and record the first error, which is
+returned by Err and when the sequence is defined
+
+The commands are kept without device address, as the
+indexer stores them, so a sequence can be built offline
+and serialized as text (e.g., "MN A10.00 V5.00 D25000 G")
+*/
+type Sequence struct {
+	commands []string
+	err      error
+}
+
+/*
+Creates an empty sequence
+*/
+func NewSequence() *Sequence {
+	return &Sequence{}
+}
+
+/*
+Parses a sequence from its text form, where the commands
+are delimited by spaces as reported by XU
+*/
+func ParseSequence(text string) (*Sequence, error) {
+	s := NewSequence()
+	for _, command := range strings.Fields(text) {
+		s.Command(command)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Sequence) add(command string) *Sequence {
+	if s.err == nil {
+		s.commands = append(s.commands, command)
+	}
+	return s
+}
+
+func (s *Sequence) fail(format string, args ...any) *Sequence {
+	return s.check(fmt.Errorf(format, args...))
+}
+
+func (s *Sequence) check(err error) *Sequence {
+	if s.err == nil {
+		s.err = err
+	}
+	return s
+}
+
+/*
+Appends a raw command without device address
+*/
+func (s *Sequence) Command(command string) *Sequence {
+	if command == "" || strings.ContainsAny(command, " \t\r\n") {
+		return s.fail("%w: invalid sequence command %q", ErrInvalidParameter, command)
+	}
+	if command[0] >= '0' && command[0] <= '9' {
+		return s.fail("%w: sequence command %q must not include the device address", ErrInvalidParameter, command)
+	}
+	return s.add(command)
+}
+
+/*
+Sets the motor to move a defined number of steps (MN)
+*/
+func (s *Sequence) NormalMode() *Sequence {
+	return s.add("MN")
+}
+
+/*
+Sets the motor to move continuously until stopped (MC)
+*/
+func (s *Sequence) ContinuousMode() *Sequence {
+	return s.add("MC")
+}
+
+/*
+Sets the positioning mode to absolute (MPA)
+*/
+func (s *Sequence) AbsoluteMode() *Sequence {
+	return s.add("MPA")
+}
+
+/*
+Sets the positioning mode to incremental (MPI)
+*/
+func (s *Sequence) IncrementalMode() *Sequence {
+	return s.add("MPI")
+}
+
+/*
+Sets the velocity in rps (V)
+*/
+func (s *Sequence) Velocity(value float64) *Sequence {
+	if err := checkRange("velocity", value, MinVelocity, MaxVelocity); err != nil {
+		return s.check(err)
+	}
+	return s.add(fmt.Sprintf("V%.2f", value))
+}
+
+/*
+Sets the acceleration in rps² (A)
+*/
+func (s *Sequence) Acceleration(value float64) *Sequence {
+	if err := checkRange("acceleration", value, MinAcceleration, MaxAcceleration); err != nil {
+		return s.check(err)
+	}
+	return s.add(fmt.Sprintf("A%.2f", value))
+}
+
+/*
+Sets the distance or absolute position in steps (D)
+*/
+func (s *Sequence) Distance(value int) *Sequence {
+	if err := checkRange("distance", value, -MaxDistance, MaxDistance); err != nil {
+		return s.check(err)
+	}
+	return s.add(fmt.Sprintf("D%d", value))
+}
+
+/*
+Sets the direction of the next moves (H)
+*/
+func (s *Sequence) Direction(direction Direction) *Sequence {
+	if direction != Forward && direction != Backward && direction != Toggle {
+		return s.fail("%w: direction %q", ErrInvalidParameter, direction)
+	}
+	return s.add("H" + string(direction))
+}
+
+/*
+Executes a move with the current settings (G)
+*/
+func (s *Sequence) Go() *Sequence {
+	return s.add("G")
+}
+
+/*
+Waits the given number of seconds before executing the
+next command (T)
+*/
+func (s *Sequence) Delay(seconds float64) *Sequence {
+	if err := checkRange("delay", seconds, MinDelay, MaxDelay); err != nil {
+		return s.check(err)
+	}
+	return s.add(fmt.Sprintf("T%.2f", seconds))
+}
+
+/*
+Starts a loop that repeats the commands up to the matching
+EndLoop the given number of times, where zero loops
+indefinitely (L)
+*/
+func (s *Sequence) Loop(count uint) *Sequence {
+	return s.add(fmt.Sprintf("L%d", count))
+}
+
+/*
+Ends the innermost loop (N)
+*/
+func (s *Sequence) EndLoop() *Sequence {
+	return s.add("N")
+}
+
+/*
+Waits until the trigger inputs 1 to 3 match the pattern, where
+each character is '1' (high), '0' (low) or 'X' (ignored) (TR)
+*/
+func (s *Sequence) WaitForTrigger(pattern string) *Sequence {
+	if !validPattern(pattern, 3) {
+		return s.fail("%w: trigger pattern must have up to 3 characters of 1, 0 or X, got %q", ErrInvalidParameter, pattern)
+	}
+	return s.add("TR" + pattern)
+}
+
+/*
+Sets the programmable outputs 1 and 2, where each character
+is '1' (on), '0' (off) or 'X' (unchanged) (O)
+*/
+func (s *Sequence) SetOutputs(pattern string) *Sequence {
+	if !validPattern(pattern, 2) {
+		return s.fail("%w: output pattern must have up to 2 characters of 1, 0 or X, got %q", ErrInvalidParameter, pattern)
+	}
+	return s.add("O" + pattern)
+}
+
+/*
+Returns true if the pattern has up to size characters of 1, 0 or X
+*/
+func validPattern(pattern string, size int) bool {
+	if len(pattern) == 0 || len(pattern) > size {
+		return false
+	}
+	for _, c := range pattern {
+		if c != '0' && c != '1' && c != 'X' {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Returns a copy of the commands of the sequence
+*/
+func (s *Sequence) Commands() []string {
+	return append([]string(nil), s.commands...)
+}
+
+/*
+Returns the first error recorded by the builder methods
+*/
+func (s *Sequence) Err() error {
+	return s.err
+}
+
+/*
+Checks that the sequence was built without errors, is not
+empty, has balanced loops and fits in the indexer memory
+*/
+func (s *Sequence) Validate() error {
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.commands) == 0 {
+		return fmt.Errorf("%w: sequence is empty", ErrInvalidParameter)
+	}
+	depth := 0
+	for _, command := range s.commands {
+		if command == "N" {
+			depth--
+		} else if strings.HasPrefix(command, "L") && !strings.HasPrefix(command, "LD") {
+			depth++
+		}
+		if depth < 0 {
+			return fmt.Errorf("%w: sequence ends a loop that was not started", ErrInvalidParameter)
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("%w: sequence has %d loops without end", ErrInvalidParameter, depth)
+	}
+	if length := len(s.String()); length > MaxSequenceLength {
+		return fmt.Errorf("%w: sequence has %d characters, must be up to %d", ErrInvalidParameter, length, MaxSequenceLength)
+	}
+	return nil
+}
+
+/*
+Returns the commands delimited by spaces, as reported by XU
+*/
+func (s *Sequence) String() string {
+	return strings.Join(s.commands, " ")
+}
+
+/*
+Encodes the sequence in its text form
+*/
+func (s *Sequence) MarshalText() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(s.String()), nil
+}
+
+/*
+Decodes the sequence from its text form
+*/
+func (s *Sequence) UnmarshalText(text []byte) error {
+	parsed, err := ParseSequence(string(text))
+	if err != nil {
+		return err
+	}
+	*s = *parsed
+	return nil
+}
+
+type SequenceStatus int
+type SequenceRunStatus int
+
+const (
+	SequenceEmpty       SequenceStatus = 0
+	SequenceBadChecksum SequenceStatus = 1
+	SequenceOK          SequenceStatus = 3
+)
+
+const (
+	SequenceRunOK          SequenceRunStatus = 0
+	SequenceRunInLoop      SequenceRunStatus = 1
+	SequenceRunInvalid     SequenceRunStatus = 2
+	SequenceRunErased      SequenceRunStatus = 3
+	SequenceRunBadChecksum SequenceRunStatus = 4
+	SequenceRunRunning     SequenceRunStatus = 5
+	SequenceRunStopped     SequenceRunStatus = 6
+)
+
+func (s SequenceStatus) String() string {
+	switch s {
+	case SequenceEmpty:
+		return "empty"
+	case SequenceBadChecksum:
+		return "bad checksum"
+	case SequenceOK:
+		return "ok"
+	}
+	return fmt.Sprintf("unknown (%d)", int(s))
+}
+
+func (s SequenceRunStatus) String() string {
+	switch s {
+	case SequenceRunOK:
+		return "successful"
+	case SequenceRunInLoop:
+		return "in a loop"
+	case SequenceRunInvalid:
+		return "invalid sequence"
+	case SequenceRunErased:
+		return "erased"
+	case SequenceRunBadChecksum:
+		return "bad checksum"
+	case SequenceRunRunning:
+		return "running"
+	case SequenceRunStopped:
+		return "killed or stopped"
+	}
+	return fmt.Sprintf("unknown (%d)", int(s))
+}
+
+func validSequenceNumber(number uint) error {
+	return checkRange("sequence number", number, MinSequence, MaxSequence)
+}
+
+/*
+Stores the sequence in the indexer memory, erasing the
+previous sequence with the same number, and verifies that
+the definition was successful (XE, XD ... XT and XSD). When
+a command fails after XD, the definition is still ended
+with XT and the partial sequence is erased
+
+The commands are sent with the device address so that the
+other indexers of the daisy chain ignore them
+*/
+func (o *OEM750x) DefineSequence(channel uint, number uint, sequence *Sequence) error {
+	if err := validSequenceNumber(number); err != nil {
+		return err
+	}
+	if err := sequence.Validate(); err != nil {
+		return err
+	}
+	if err := o.EraseSequence(channel, number); err != nil {
+		return err
+	}
+	if err := o.Write(fmt.Sprintf("%dXD%d", channel, number)); err != nil {
+		return err
+	}
+	for _, command := range sequence.commands {
+		if err := o.writeOnce(fmt.Sprintf("%d%s", channel, command)); err != nil {
+			return errors.Join(err, o.abortDefinition(channel, number))
+		}
+	}
+	if err := o.Write(fmt.Sprintf("%dXT", channel)); err != nil {
+		return errors.Join(err, o.abortDefinition(channel, number))
+	}
+
+	status, err := o.RequestInt(fmt.Sprintf("%dXSD", channel))
+	if err != nil {
+		return err
+	}
+	switch status {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%w: sequence %d already exists", ErrInvalidParameter, number)
+	case 2:
+		return fmt.Errorf("%w: out of memory defining sequence %d", ErrInvalidParameter, number)
+	}
+	return fmt.Errorf("%w: unknown sequence definition status %d", ErrInvalidResponse, status)
+}
+
+/*
+Ends a definition that failed after XD, so that the drive
+executes the next commands instead of storing them, and
+erases the partial sequence
+*/
+func (o *OEM750x) abortDefinition(channel uint, number uint) error {
+	if err := o.Write(fmt.Sprintf("%dXT", channel)); err != nil {
+		return err
+	}
+	return o.EraseSequence(channel, number)
+}
+
+/*
+Deletes a sequence from the indexer memory
+*/
+func (o *OEM750x) EraseSequence(channel uint, number uint) error {
+	if err := validSequenceNumber(number); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%dXE%d", channel, number)
+	return o.Write(msg)
+}
+
+/*
+Loads a stored sequence into the command buffer and
+executes it. Use WaitForMove to wait until it ends
+*/
+func (o *OEM750x) RunSequence(channel uint, number uint) error {
+	if err := validSequenceNumber(number); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("%dXR%d", channel, number)
+	return o.Write(msg)
+}
+
+/*
+Reads back the contents of a stored sequence (XU). On a
+daisy chain the contents pass through every indexer
+between the host and the channel
+*/
+func (o *OEM750x) UploadSequence(channel uint, number uint) (*Sequence, error) {
+	if err := validSequenceNumber(number); err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("%dXU%d", channel, number)
+	response, err := o.Request(msg)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(string(response), "*")
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: sequence %d is empty", ErrInvalidResponse, number)
+	}
+	return ParseSequence(text)
+}
+
+/*
+Checks that the stored sequence has the same commands
+as the given sequence
+*/
+func (o *OEM750x) VerifySequence(channel uint, number uint, sequence *Sequence) error {
+	stored, err := o.UploadSequence(channel, number)
+	if err != nil {
+		return err
+	}
+	if stored.String() != sequence.String() {
+		return fmt.Errorf("%w: sequence %d differs, stored %q, expected %q", ErrInvalidResponse, number, stored, sequence)
+	}
+	return nil
+}
+
+/*
+Gets whether a stored sequence is empty, has a bad
+checksum or is ok (XSS)
+*/
+func (o *OEM750x) GetSequenceStatus(channel uint, number uint) (SequenceStatus, error) {
+	return o.GetSequenceStatusContext(context.Background(), channel, number)
+}
+
+/*
+Same as GetSequenceStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetSequenceStatusContext(ctx context.Context, channel uint, number uint) (SequenceStatus, error) {
+	if err := validSequenceNumber(number); err != nil {
+		return 0, err
+	}
+	msg := fmt.Sprintf("%dXSS%d", channel, number)
+	status, err := o.RequestIntContext(ctx, msg)
+	return SequenceStatus(status), err
+}
+
+/*
+Gets the status of the last sequence executed (XSR)
+*/
+func (o *OEM750x) GetSequenceRunStatus(channel uint) (SequenceRunStatus, error) {
+	return o.GetSequenceRunStatusContext(context.Background(), channel)
+}
+
+/*
+Same as GetSequenceRunStatus, giving up when the context is done
+*/
+func (o *OEM750x) GetSequenceRunStatusContext(ctx context.Context, channel uint) (SequenceRunStatus, error) {
+	msg := fmt.Sprintf("%dXSR", channel)
+	status, err := o.RequestIntContext(ctx, msg)
+	return SequenceRunStatus(status), err
+}
+
+/*
+Gets the checksum of the sequence memory (XC) that stays
+the same while the indexer is not reprogrammed
+*/
+func (o *OEM750x) GetSequenceChecksum(channel uint) (int, error) {
+	return o.GetSequenceChecksumContext(context.Background(), channel)
+}
+
+/*
+Same as GetSequenceChecksum, giving up when the context is done
+*/
+func (o *OEM750x) GetSequenceChecksumContext(ctx context.Context, channel uint) (int, error) {
+	msg := fmt.Sprintf("%dXC", channel)
+	response, err := o.RequestContext(ctx, msg)
+	if err != nil {
+		return 0, err
+	}
+	checksum, err := strconv.Atoi(strings.TrimPrefix(string(response), "*"))
+	if err != nil {
+		return 0, &ParseError{Expected: "checksum", Response: string(response)}
+	}
+	return checksum, nil
+}
+
+/*
+Returns the numbers of the sequences stored in the indexer
+*/
+func (o *OEM750x) ListSequences(channel uint) ([]uint, error) {
+	var numbers []uint
+	for number := MinSequence; number <= MaxSequence; number++ {
+		status, err := o.GetSequenceStatus(channel, number)
+		if err != nil {
+			return nil, err
+		}
+		if status != SequenceEmpty {
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers, nil
+}
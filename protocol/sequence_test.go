@@ -0,0 +1,156 @@
+package protocol_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestSequenceBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		sequence *protocol.Sequence
+		expected string
+		err      error
+	}{
+		{
+			"move",
+			protocol.NewSequence().NormalMode().Acceleration(10).Velocity(5).Distance(25000).Go(),
+			"MN A10.00 V5.00 D25000 G", nil,
+		},
+		{
+			"loop",
+			protocol.NewSequence().Loop(3).WaitForTrigger("1X0").Go().Delay(0.5).SetOutputs("X1").EndLoop(),
+			"L3 TR1X0 G T0.50 OX1 N", nil,
+		},
+		{"empty", protocol.NewSequence(), "", protocol.ErrInvalidParameter},
+		{"velocity", protocol.NewSequence().Velocity(60).Go(), "", protocol.ErrOutOfRange},
+		{"trigger", protocol.NewSequence().WaitForTrigger("102"), "", protocol.ErrInvalidParameter},
+		{"unbalanced", protocol.NewSequence().Loop(2).Go(), "", protocol.ErrInvalidParameter},
+		{"unstarted", protocol.NewSequence().Go().EndLoop(), "", protocol.ErrInvalidParameter},
+		{"address", protocol.NewSequence().Command("1G"), "", protocol.ErrInvalidParameter},
+		{"length", protocol.NewSequence().Command("T" + strings.Repeat("0", 255)), "", protocol.ErrInvalidParameter},
+	}
+	for _, test := range tests {
+		err := test.sequence.Validate()
+		if !errors.Is(err, test.err) || (test.err != nil) != (err != nil) {
+			t.Fatalf("%s: unexpected validation result %v", test.name, err)
+		}
+		if test.err == nil && test.sequence.String() != test.expected {
+			t.Fatalf("%s: sequence = %q", test.name, test.sequence)
+		}
+	}
+
+	type program struct {
+		Home *protocol.Sequence `json:"home"`
+	}
+	data, err := json.Marshal(program{Home: protocol.NewSequence().Direction(protocol.Backward).Go()})
+	if err != nil || string(data) != `{"home":"H- G"}` {
+		t.Fatalf("json = %s, %v", data, err)
+	}
+	var decoded program
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Home.String() != "H- G" {
+		t.Fatalf("decoded = %v, %v", decoded.Home, err)
+	}
+	if err := json.Unmarshal([]byte(`{"home":"L2 G"}`), &decoded); err == nil {
+		t.Fatal("expected an error for an unbalanced loop")
+	}
+}
+
+func TestDefineAndRunSequence(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+
+	sequence := protocol.NewSequence().
+		NormalMode().IncrementalMode().Distance(500).
+		Loop(3).Go().Delay(0.01).EndLoop()
+	if err := parker.DefineSequence(1, 2, sequence); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.VerifySequence(1, 2, sequence); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.VerifySequence(1, 2, protocol.NewSequence().Go()); !errors.Is(err, protocol.ErrInvalidResponse) {
+		t.Fatal("expected verification to fail for different contents")
+	}
+	numbers, err := parker.ListSequences(1)
+	if err != nil || !slices.Equal(numbers, []uint{2}) {
+		t.Fatalf("sequences = %v, %v", numbers, err)
+	}
+	if _, err := parker.GetSequenceChecksum(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parker.RunSequence(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.WaitForMove(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if position := sim.Position(1); position != 1500 {
+		t.Fatalf("position after sequence = %d", position)
+	}
+	status, err := parker.GetSequenceRunStatus(1)
+	if err != nil || status != protocol.SequenceRunOK {
+		t.Fatalf("run status = %v, %v", status, err)
+	}
+
+	if err := parker.EraseSequence(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := parker.GetSequenceStatus(1, 2); err != nil || state != protocol.SequenceEmpty {
+		t.Fatalf("sequence status = %v, %v", state, err)
+	}
+	if _, err := parker.UploadSequence(1, 2); !errors.Is(err, protocol.ErrInvalidResponse) {
+		t.Fatal("expected an error uploading an erased sequence")
+	}
+	if err := parker.RunSequence(1, 8); err == nil {
+		t.Fatal("expected an error for an invalid sequence number")
+	}
+}
+
+func TestDefineSequenceEndsDefinitionOnFailure(t *testing.T) {
+	transport := &failingTransport{Unicomm: simulator.New(simulator.Options{})}
+	transport.failing("1D500")
+	parker := oem750x.NewWithCommunication(transport)
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer parker.Disconnect()
+
+	sequence := protocol.NewSequence().Velocity(2).Distance(500).Go()
+	if err := parker.DefineSequence(1, 3, sequence); err == nil {
+		t.Fatal("expected the failed write to be reported")
+	}
+	if err := parker.SetTargetDistance(1, 700); err != nil {
+		t.Fatal(err)
+	}
+	if distance, err := parker.GetTargetDistance(1); err != nil || distance != 700 {
+		t.Fatalf("distance after the failed definition = %d, %v", distance, err)
+	}
+	if status, err := parker.GetSequenceStatus(1, 3); err != nil || status != protocol.SequenceEmpty {
+		t.Fatalf("sequence status = %v, %v", status, err)
+	}
+}
+
+func TestDefineSequenceReportsStatus(t *testing.T) {
+	for _, status := range []string{"1", "2", "7"} {
+		transport := &recordedTransport{responses: map[string]string{"1XSD": "*" + status + "\r"}}
+		parker := &protocol.OEM750x{Communication: transport}
+		err := parker.DefineSequence(1, 2, protocol.NewSequence().Go())
+		expected := protocol.ErrInvalidParameter
+		if status == "7" {
+			expected = protocol.ErrInvalidResponse
+		}
+		if !errors.Is(err, expected) {
+			t.Fatalf("status %s: expected %v, got %v", status, expected, err)
+		}
+	}
+}
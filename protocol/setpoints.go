@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -9,19 +10,39 @@ Sets thet target velocity of the motor in revolutions
 per second (rps)
 */
 func (o *OEM750x) SetTargetVelocity(channel uint, value float64) error {
-	if value < 0.001 || value > 50.0 {
-		return fmt.Errorf("motor velocity must be between 0.01 and 50.0 rps")
+	return o.SetTargetVelocityContext(context.Background(), channel, value)
+}
+
+/*
+Same as SetTargetVelocity, giving up when the context is done
+*/
+func (o *OEM750x) SetTargetVelocityContext(ctx context.Context, channel uint, value float64) error {
+	if err := checkRange("velocity", value, MinVelocity, MaxVelocity); err != nil {
+		return err
 	}
 	msg := fmt.Sprintf("%dV%.2f", channel, value)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.velocity = value
+	})
+	return nil
 }
 
 /*
 Gets the target velocity of the motor in rps
 */
 func (o *OEM750x) GetTargetVelocity(channel uint) (float64, error) {
+	return o.GetTargetVelocityContext(context.Background(), channel)
+}
+
+/*
+Same as GetTargetVelocity, giving up when the context is done
+*/
+func (o *OEM750x) GetTargetVelocityContext(ctx context.Context, channel uint) (float64, error) {
 	msg := fmt.Sprintf("%dV", channel)
-	return o.RequestFloat(msg)
+	return o.RequestFloatContext(ctx, msg)
 }
 
 /*
@@ -29,36 +50,82 @@ Sets the target acceleration of the motor in revolutions
 per second squared (rps²)
 */
 func (o *OEM750x) SetTargetAcceleration(channel uint, value float64) error {
-	if value < 0.01 || value > 999.0 {
-		return fmt.Errorf("motor acceleration must be between 0.01 and 100.0 rps²")
+	return o.SetTargetAccelerationContext(context.Background(), channel, value)
+}
+
+/*
+Same as SetTargetAcceleration, giving up when the context is done
+*/
+func (o *OEM750x) SetTargetAccelerationContext(ctx context.Context, channel uint, value float64) error {
+	if err := checkRange("acceleration", value, MinAcceleration, MaxAcceleration); err != nil {
+		return err
 	}
 	msg := fmt.Sprintf("%dA%.2f", channel, value)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.acceleration = value
+	})
+	return nil
 }
 
 /*
 Gets the target acceleration of the motor in rps²
 */
 func (o *OEM750x) GetTargetAcceleration(channel uint) (float64, error) {
+	return o.GetTargetAccelerationContext(context.Background(), channel)
+}
+
+/*
+Same as GetTargetAcceleration, giving up when the context is done
+*/
+func (o *OEM750x) GetTargetAccelerationContext(ctx context.Context, channel uint) (float64, error) {
 	msg := fmt.Sprintf("%dA", channel)
-	return o.RequestFloat(msg)
+	return o.RequestFloatContext(ctx, msg)
 }
 
 /*
 Sets the target distance of the motor in steps
 */
 func (o *OEM750x) SetTargetDistance(channel uint, value int) error {
-	if value > 2147483648 || value < -2147483648 {
-		return fmt.Errorf("distance must be between ±2147483648")
+	return o.SetTargetDistanceContext(context.Background(), channel, value)
+}
+
+/*
+Same as SetTargetDistance, giving up when the context is done
+*/
+func (o *OEM750x) SetTargetDistanceContext(ctx context.Context, channel uint, value int) error {
+	if err := checkRange("distance", value, -MaxDistance, MaxDistance); err != nil {
+		return err
 	}
 	msg := fmt.Sprintf("%dD%d", channel, value)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.distance = value
+		state.hasDistance = true
+		if value > 0 {
+			state.direction = 1
+		} else if value < 0 {
+			state.direction = -1
+		}
+	})
+	return nil
 }
 
 /*
 Gets the target distance of the motor in steps
 */
 func (o *OEM750x) GetTargetDistance(channel uint) (int, error) {
+	return o.GetTargetDistanceContext(context.Background(), channel)
+}
+
+/*
+Same as GetTargetDistance, giving up when the context is done
+*/
+func (o *OEM750x) GetTargetDistanceContext(ctx context.Context, channel uint) (int, error) {
 	msg := fmt.Sprintf("%dD", channel)
-	return o.RequestInt(msg)
+	return o.RequestIntContext(ctx, msg)
 }
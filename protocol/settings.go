@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -37,11 +38,24 @@ Sets the indexer to operate with incremental or absolute
 when in steps mode
 */
 func (o *OEM750x) SetIndexerMovementMode(channel uint, mode MovementMode) error {
+	return o.SetIndexerMovementModeContext(context.Background(), channel, mode)
+}
+
+/*
+Same as SetIndexerMovementMode, giving up when the context is done
+*/
+func (o *OEM750x) SetIndexerMovementModeContext(ctx context.Context, channel uint, mode MovementMode) error {
 	if mode != Incremental && mode != Absolute {
-		return fmt.Errorf("invalid movement mode: %d", mode)
+		return fmt.Errorf("%w: movement mode %d", ErrInvalidParameter, mode)
 	}
 	msg := fmt.Sprintf("%dFSA%d", channel, mode)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.absolute = mode == Absolute
+	})
+	return nil
 }
 
 /*
@@ -49,11 +63,18 @@ Sets the active state of clockwise (CW) and counter-clockwise (CCW)
 end-of-travel limit switches
 */
 func (o *OEM750x) SetEndLimitsState(channel uint, mode SwitchState) error {
+	return o.SetEndLimitsStateContext(context.Background(), channel, mode)
+}
+
+/*
+Same as SetEndLimitsState, giving up when the context is done
+*/
+func (o *OEM750x) SetEndLimitsStateContext(ctx context.Context, channel uint, mode SwitchState) error {
 	if mode != NormallyClosed && mode != NormallyOpen {
-		return fmt.Errorf("invalid switch state: %d", mode)
+		return fmt.Errorf("%w: switch state %d", ErrInvalidParameter, mode)
 	}
 	msg := fmt.Sprintf("%dOSA%d", channel, mode)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
@@ -61,12 +82,19 @@ Sets back up to home, that when is active reversing over
 the selected edge to ensure a precise and repeatable home position.
 */
 func (o *OEM750x) SetBackUpHome(channel uint, status bool) error {
+	return o.SetBackUpHomeContext(context.Background(), channel, status)
+}
+
+/*
+Same as SetBackUpHome, giving up when the context is done
+*/
+func (o *OEM750x) SetBackUpHomeContext(ctx context.Context, channel uint, status bool) error {
 	var value int = 0
 	if status {
 		value = 1
 	}
 	msg := fmt.Sprintf("%dOSB%d", channel, value)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
@@ -74,77 +102,203 @@ Sets the active state of home switch, 0 (active is closed) and
 1 (active is open)
 */
 func (o *OEM750x) SetActiveStateHomeSwitch(channel uint, state SwitchState) error {
+	return o.SetActiveStateHomeSwitchContext(context.Background(), channel, state)
+}
+
+/*
+Same as SetActiveStateHomeSwitch, giving up when the context is done
+*/
+func (o *OEM750x) SetActiveStateHomeSwitchContext(ctx context.Context, channel uint, state SwitchState) error {
 	if state != NormallyClosed && state != NormallyOpen {
-		return fmt.Errorf("active state must be 0 (closed) or 1 (open), got %d", state)
+		return fmt.Errorf("%w: active state must be 0 (closed) or 1 (open), got %d", ErrInvalidParameter, state)
 	}
 	msg := fmt.Sprintf("%dOSC%d", channel, state)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Sets the reference edge of home switch
 */
 func (o *OEM750x) SetHomeEdge(channel uint, edge Edge) error {
+	return o.SetHomeEdgeContext(context.Background(), channel, edge)
+}
+
+/*
+Same as SetHomeEdge, giving up when the context is done
+*/
+func (o *OEM750x) SetHomeEdgeContext(ctx context.Context, channel uint, edge Edge) error {
 	if edge != EdgeCW && edge != EdgeCCW {
-		return fmt.Errorf("edge must be 0 (CW) or 1 (CCW), got %d", edge)
+		return fmt.Errorf("%w: edge must be 0 (CW) or 1 (CCW), got %d", ErrInvalidParameter, edge)
 	}
 	msg := fmt.Sprintf("%dOSH%d", channel, edge)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Sets the encoder Z channel as the final home reference
+inside the region of the home switch. Requires back up to
+home (OSB1) and encoder step mode (FSB1)
+*/
+func (o *OEM750x) SetHomeIndex(channel uint, enable bool) error {
+	return o.SetHomeIndexContext(context.Background(), channel, enable)
+}
+
+/*
+Same as SetHomeIndex, giving up when the context is done
+*/
+func (o *OEM750x) SetHomeIndexContext(ctx context.Context, channel uint, enable bool) error {
+	var value int = 0
+	if enable {
+		value = 1
+	}
+	msg := fmt.Sprintf("%dOSD%d", channel, value)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Sets stall detection, that compares the motor and encoder
+positions. Only works in encoder step mode (FSB1)
+*/
+func (o *OEM750x) SetStallDetection(channel uint, enable bool) error {
+	return o.SetStallDetectionContext(context.Background(), channel, enable)
+}
+
+/*
+Same as SetStallDetection, giving up when the context is done
+*/
+func (o *OEM750x) SetStallDetectionContext(ctx context.Context, channel uint, enable bool) error {
+	var value int = 0
+	if enable {
+		value = 1
+	}
+	msg := fmt.Sprintf("%dFSH%d", channel, value)
+	return o.WriteContext(ctx, msg)
+}
+
+/*
+Sets the indexer to stop the move immediately, without
+deceleration, when a stall is detected. Requires stall
+detection (FSH1)
+*/
+func (o *OEM750x) SetStopOnStall(channel uint, enable bool) error {
+	return o.SetStopOnStallContext(context.Background(), channel, enable)
+}
+
+/*
+Same as SetStopOnStall, giving up when the context is done
+*/
+func (o *OEM750x) SetStopOnStallContext(ctx context.Context, channel uint, enable bool) error {
+	var value int = 0
+	if enable {
+		value = 1
+	}
+	msg := fmt.Sprintf("%dFSD%d", channel, value)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Sets the indexer to perfom moves in motor steps or encoder steps
 */
 func (o *OEM750x) SetIndexerMode(channel uint, mode IndexerMode) error {
+	return o.SetIndexerModeContext(context.Background(), channel, mode)
+}
+
+/*
+Same as SetIndexerMode, giving up when the context is done
+*/
+func (o *OEM750x) SetIndexerModeContext(ctx context.Context, channel uint, mode IndexerMode) error {
 	if mode != MotorSteps && mode != EncoderSteps {
-		return fmt.Errorf("invalid indexer mode: %d", mode)
+		return fmt.Errorf("%w: indexer mode %d", ErrInvalidParameter, mode)
 	}
 	msg := fmt.Sprintf("%dFSB%d", channel, mode)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Sets the direction polarity of the motor
 */
 func (o *OEM750x) SetPolarity(channel uint, polarity Polarity) error {
+	return o.SetPolarityContext(context.Background(), channel, polarity)
+}
+
+/*
+Same as SetPolarity, giving up when the context is done
+*/
+func (o *OEM750x) SetPolarityContext(ctx context.Context, channel uint, polarity Polarity) error {
 	if polarity != Normal && polarity != Inverted {
-		return fmt.Errorf("invalid polarity: %d", polarity)
+		return fmt.Errorf("%w: polarity %d", ErrInvalidParameter, polarity)
 	}
 	msg := fmt.Sprintf("%dCMDDIR%d", channel, polarity)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Gets the direction polarity of the motor
 */
 func (o *OEM750x) GetPolarity(channel uint) (int, error) {
+	return o.GetPolarityContext(context.Background(), channel)
+}
+
+/*
+Same as GetPolarity, giving up when the context is done
+*/
+func (o *OEM750x) GetPolarityContext(ctx context.Context, channel uint) (int, error) {
 	msg := fmt.Sprintf("%dCMDDIR", channel)
-	return o.RequestInt(msg)
+	return o.RequestIntContext(ctx, msg)
 }
 
 /*
 Sets the resolution of the motor in steps per revolution
 */
 func (o *OEM750x) SetResolution(channel uint, value uint) error {
-	if value < 200 || value > 50800 {
-		return fmt.Errorf("motor resolution must be between 200 and 50800")
+	return o.SetResolutionContext(context.Background(), channel, value)
+}
+
+/*
+Same as SetResolution, giving up when the context is done
+*/
+func (o *OEM750x) SetResolutionContext(ctx context.Context, channel uint, value uint) error {
+	if err := checkRange("resolution", value, 200, 50800); err != nil {
+		return err
 	}
 	msg := fmt.Sprintf("%dMR%d", channel, value)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		state.resolution = value
+	})
+	return nil
 }
 
 /*
 Gets current motor resolution in steps per revolution
 */
 func (o *OEM750x) GetResolution(channel uint) (int, error) {
+	return o.GetResolutionContext(context.Background(), channel)
+}
+
+/*
+Same as GetResolution, giving up when the context is done
+*/
+func (o *OEM750x) GetResolutionContext(ctx context.Context, channel uint) (int, error) {
 	msg := fmt.Sprintf("%dMR", channel)
-	return o.RequestInt(msg)
+	return o.RequestIntContext(ctx, msg)
 }
 
 /*
-Sets status of communication error checking
+Sets status of communication error checking. The mode is
+tracked for the channel, so the echoes of the following
+commands are read as the drive sends them
 */
 func (o *OEM750x) SetErrorChecking(channel uint, enable bool) error {
+	return o.SetErrorCheckingContext(context.Background(), channel, enable)
+}
+
+/*
+Same as SetErrorChecking, giving up when the context is done
+*/
+func (o *OEM750x) SetErrorCheckingContext(ctx context.Context, channel uint, enable bool) error {
 	var value uint
 	if enable {
 		value = 1
@@ -152,7 +306,19 @@ func (o *OEM750x) SetErrorChecking(channel uint, enable bool) error {
 		value = 0
 	}
 	msg := fmt.Sprintf("%dSSE%d", channel, value)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	channels := []uint{channel}
+	if channel == 0 {
+		channels = append(channels, o.stateChannels()...)
+	}
+	for _, channel := range channels {
+		o.updateState(channel, func(state *channelState) {
+			state.errorChecking = enable
+		})
+	}
+	return nil
 }
 
 /*
@@ -161,6 +327,13 @@ the motor current to zero and the system will ignore move
 commands
 */
 func (o *OEM750x) SetShutdown(channel uint, enable bool) error {
+	return o.SetShutdownContext(context.Background(), channel, enable)
+}
+
+/*
+Same as SetShutdown, giving up when the context is done
+*/
+func (o *OEM750x) SetShutdownContext(ctx context.Context, channel uint, enable bool) error {
 	var value uint
 	if enable {
 		value = 1
@@ -168,34 +341,68 @@ func (o *OEM750x) SetShutdown(channel uint, enable bool) error {
 		value = 0
 	}
 	msg := fmt.Sprintf("%dST%d", channel, value)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /* Gets the shutdown status */
 func (o *OEM750x) GetShutdown(channel uint) (int, error) {
+	return o.GetShutdownContext(context.Background(), channel)
+}
+
+/*
+Same as GetShutdown, giving up when the context is done
+*/
+func (o *OEM750x) GetShutdownContext(ctx context.Context, channel uint) (int, error) {
 	msg := fmt.Sprintf("%dST", channel)
-	return o.RequestInt(msg)
+	return o.RequestIntContext(ctx, msg)
 }
 
 /*
 Sets disable status of end-of-travel limit switches
 */
 func (o *OEM750x) SetDisableSwitch(channel uint, mode DisableSwitch) error {
+	return o.SetDisableSwitchContext(context.Background(), channel, mode)
+}
+
+/*
+Same as SetDisableSwitch, giving up when the context is done
+*/
+func (o *OEM750x) SetDisableSwitchContext(ctx context.Context, channel uint, mode DisableSwitch) error {
 	if mode != EnableBoth && mode != DisableCW &&
 		mode != DisableCCW && mode != DisableBoth {
-		return fmt.Errorf("invalid disable switch mode: %d", mode)
+		return fmt.Errorf("%w: disable switch mode %d", ErrInvalidParameter, mode)
 	}
 	msg := fmt.Sprintf("%dLD%d", channel, mode)
-	return o.Write(msg)
+	return o.WriteContext(ctx, msg)
 }
 
 /*
 Sets movement direction
 */
 func (o *OEM750x) SetDirection(channel uint, direction Direction) error {
+	return o.SetDirectionContext(context.Background(), channel, direction)
+}
+
+/*
+Same as SetDirection, giving up when the context is done
+*/
+func (o *OEM750x) SetDirectionContext(ctx context.Context, channel uint, direction Direction) error {
 	if direction != Forward && direction != Backward && direction != Toggle {
-		return fmt.Errorf("invalid direction: %s", direction)
+		return fmt.Errorf("%w: direction %q", ErrInvalidParameter, direction)
 	}
 	msg := fmt.Sprintf("%dH%s", channel, direction)
-	return o.Write(msg)
+	if err := o.WriteContext(ctx, msg); err != nil {
+		return err
+	}
+	o.updateState(channel, func(state *channelState) {
+		switch direction {
+		case Forward:
+			state.direction = 1
+		case Backward:
+			state.direction = -1
+		default:
+			state.direction = -state.direction
+		}
+	})
+	return nil
 }
@@ -0,0 +1,105 @@
+package protocol
+
+import (
+	"context"
+	"time"
+)
+
+/*
+Stall reported by the closed loop status (RC) of a channel,
+with the position of the motor when the monitor saw it and
+the direction of the move that stalled
+*/
+type StallEvent struct {
+	Channel   uint
+	Time      time.Time
+	Position  int
+	Direction Direction
+}
+
+/*
+Action run by the monitor when a stall is detected on a
+channel, e.g. backing off, homing again or shutting down.
+The context is the one given to the monitor Run
+*/
+type StallRecovery func(ctx context.Context, o *OEM750x, event StallEvent) error
+
+/*
+Enables stall detection (FSH) and stopping the move
+without deceleration on a stall (FSD). Both only work in
+encoder step mode (FSB1)
+*/
+func (o *OEM750x) EnableStallDetection(channel uint, stop bool) error {
+	if err := o.SetStallDetection(channel, true); err != nil {
+		return err
+	}
+	return o.SetStopOnStall(channel, stop)
+}
+
+/*
+Returns a recovery that stops the motor and moves it back
+by the number of steps, away from the stall
+*/
+func BackOffRecovery(steps uint) StallRecovery {
+	return func(ctx context.Context, o *OEM750x, event StallEvent) error {
+		if err := o.halt(ctx, event.Channel); err != nil {
+			return err
+		}
+		distance := -int(steps)
+		if event.Direction == Backward {
+			distance = int(steps)
+		}
+		return o.MoveBy(ctx, event.Channel, distance)
+	}
+}
+
+/*
+Returns a recovery that stops the motor and homes the
+channel again with the strategy
+*/
+func RehomeRecovery(strategy HomingStrategy) StallRecovery {
+	return func(ctx context.Context, o *OEM750x, event StallEvent) error {
+		if err := o.halt(ctx, event.Channel); err != nil {
+			return err
+		}
+		return o.Home(ctx, event.Channel, strategy)
+	}
+}
+
+/*
+Returns a recovery that shuts the motor down (ST1), so it
+must be energized again with SetShutdown before moving
+*/
+func ShutdownRecovery() StallRecovery {
+	return func(ctx context.Context, o *OEM750x, event StallEvent) error {
+		return o.SetShutdown(event.Channel, true)
+	}
+}
+
+/*
+Stops the motor and waits for it, accepting the attention
+raised by the stall
+*/
+func (o *OEM750x) halt(ctx context.Context, channel uint) error {
+	if err := o.Stop(channel); err != nil {
+		return err
+	}
+	return o.settle(ctx, channel, o.pollInterval())
+}
+
+/*
+Returns the direction of the move from the start position
+to the position, or the tracked direction when it did not move
+*/
+func (o *OEM750x) moveDirection(channel uint, start int, position int) Direction {
+	switch {
+	case position > start:
+		return Forward
+	case position < start:
+		return Backward
+	}
+	if o.snapshotState(channel).direction < 0 {
+		return Backward
+	}
+	return Forward
+}
@@ -0,0 +1,140 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestStallRecovery(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	sim.SetHardStops(1, -800, 800)
+	parker := newFastAxis(t, sim)
+	if err := parker.SetIndexerMode(1, protocol.EncoderSteps); err != nil {
+		t.Fatal(err)
+	} else if err := parker.EnableStallDetection(1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor, err := protocol.NewMonitor(parker, protocol.MonitorOptions{
+		Channels: []uint{1},
+		Interval: 2 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backOff := protocol.BackOffRecovery(100)
+	recovered := make(chan error, 1)
+	monitor.OnStall(1, func(ctx context.Context, o *protocol.OEM750x, event protocol.StallEvent) error {
+		err := backOff(ctx, o, event)
+		recovered <- err
+		return err
+	})
+	events, _ := monitor.Subscribe(protocol.StallDetected)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- monitor.Run(ctx) }()
+
+	for {
+		if _, ok := monitor.Snapshot(1); ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	} else if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-events:
+		expected := protocol.StallEvent{Channel: 1, Time: event.Snapshot.Time, Position: 800, Direction: protocol.Forward}
+		if event.Type != protocol.StallDetected || event.Stall == nil || *event.Stall != expected {
+			t.Fatalf("unexpected event %+v, stall %+v", event, event.Stall)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stall was not detected")
+	}
+
+	select {
+	case err := <-recovered:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stall was not recovered")
+	}
+	if position := sim.Position(1); position != 700 {
+		t.Fatalf("position after the recovery = %d", position)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("run returned %v", err)
+	}
+	for range events {
+	}
+}
+
+func TestStallNotRepeatedOnNextMove(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	sim.SetHardStops(1, -800, 800)
+	parker := newFastAxis(t, sim)
+	if err := parker.SetIndexerMode(1, protocol.EncoderSteps); err != nil {
+		t.Fatal(err)
+	} else if err := parker.EnableStallDetection(1, true); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor, err := protocol.NewMonitor(parker, protocol.MonitorOptions{
+		Channels: []uint{1},
+		Interval: 2 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ := monitor.Subscribe(protocol.StallDetected)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- monitor.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for {
+		if _, ok := monitor.Snapshot(1); ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := parker.SetContinuosMode(1); err != nil {
+		t.Fatal(err)
+	} else if err := parker.Go(1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stall was not detected")
+	}
+
+	if err := parker.SetTargetVelocity(1, 10); err != nil {
+		t.Fatal(err)
+	} else if err := parker.MoveBy(ctx, 1, -300); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case event := <-events:
+		t.Fatalf("stall of the previous move reported again: %+v", event.Snapshot)
+	default:
+	}
+}
@@ -0,0 +1,76 @@
+package protocol
+
+/*
+Settings last commanded to a channel through this instance.
+The drive cannot report every mode (e.g., MN/MC), so they are
+tracked as the commands are sent, starting from the power-up
+values of the indexer
+*/
+type channelState struct {
+	continuous    bool
+	absolute      bool
+	direction     int
+	distance      int
+	hasDistance   bool
+	velocity      float64
+	acceleration  float64
+	resolution    uint
+	limits        *SoftLimits
+	watch         uint64
+	watchErr      error
+	jogErr        error
+	errorChecking bool
+	outputs       [2]bool
+	aliases       ioAliases
+}
+
+/*
+Returns the state of the channel, creating it with the
+power-up values when it does not exist yet. The state
+mutex must be held by the caller
+*/
+func (o *OEM750x) state(channel uint) *channelState {
+	if o.states == nil {
+		o.states = make(map[uint]*channelState)
+	}
+	state, exists := o.states[channel]
+	if !exists {
+		state = &channelState{direction: 1}
+		o.states[channel] = state
+	}
+	return state
+}
+
+/*
+Applies an update to the state of the channel
+*/
+func (o *OEM750x) updateState(channel uint, update func(state *channelState)) {
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	update(o.state(channel))
+}
+
+/*
+Returns a copy of the state of the channel
+*/
+func (o *OEM750x) snapshotState(channel uint) channelState {
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	return *o.state(channel)
+}
+
+/*
+Returns the channels that have a state
+*/
+func (o *OEM750x) stateChannels() []uint {
+	o.stateMutex.Lock()
+	defer o.stateMutex.Unlock()
+
+	channels := make([]uint, 0, len(o.states))
+	for channel := range o.states {
+		channels = append(channels, channel)
+	}
+	return channels
+}
@@ -0,0 +1,242 @@
+package protocol
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Status of the end-of-travel limits reported by RA
+*/
+type LimitStatus struct {
+	LastMoveStoppedByCW  bool
+	LastMoveStoppedByCCW bool
+	CWActive             bool
+	CCWActive            bool
+}
+
+/*
+Status of the closed loop functions reported by RC
+*/
+type ClosedLoopStatus struct {
+	StaticPositionLoss   bool
+	PostMovePositionLoss bool
+	HomingFailed         bool
+	Stall                bool
+}
+
+/*
+Status of loops, pauses, shutdown and triggers reported by RB
+*/
+type ExecutionStatus struct {
+	LoopActive     bool
+	PauseActive    bool
+	ShutdownActive bool
+	TriggerActive  bool
+}
+
+/*
+Hardware state of the inputs reported by IS, where true
+means the input is high (opened)
+*/
+type InputStatus struct {
+	Triggers       [3]bool
+	Home           bool
+	Faulted        bool
+	CCWLimit       bool
+	CWLimit        bool
+	SequenceSelect [3]bool
+	Address        uint
+}
+
+/*
+State of the trigger inputs 1 to 3 reported by TS, where
+true means the input is high (opened)
+*/
+type TriggerStatus [3]bool
+
+/*
+Decodes the single character status reports where the
+character is '@' (0x40) added to a 4-bit mask, so that
+'@' means no flags set and 'O' means all flags set
+*/
+func decodeFlags(response string) ([4]bool, error) {
+	var flags [4]bool
+	if len(response) != 1 || response[0] < '@' || response[0] > 'O' {
+		return flags, &ParseError{Expected: "status flags", Response: response}
+	}
+	mask := response[0] - '@'
+	for i := range flags {
+		flags[i] = mask&(1<<i) != 0
+	}
+	return flags, nil
+}
+
+/*
+Decodes a report of '0' and '1' digits into booleans
+*/
+func decodeDigits(response string) ([]bool, error) {
+	digits := make([]bool, len(response))
+	for i, digit := range response {
+		switch digit {
+		case '0':
+		case '1':
+			digits[i] = true
+		default:
+			return nil, &ParseError{Expected: "status digits", Response: response}
+		}
+	}
+	return digits, nil
+}
+
+/*
+Formats status flags as a string of '0' and '1' characters
+in the order they are reported by the drive
+*/
+func formatFlags(flags ...bool) string {
+	var builder strings.Builder
+	for _, flag := range flags {
+		if flag {
+			builder.WriteByte('1')
+		} else {
+			builder.WriteByte('0')
+		}
+	}
+	return builder.String()
+}
+
+/*
+Parses the value of the RA response into the limits status
+*/
+func ParseLimitStatus(response string) (LimitStatus, error) {
+	flags, err := decodeFlags(response)
+	if err != nil {
+		return LimitStatus{}, &ParseError{Expected: "limits status", Response: response}
+	}
+	return LimitStatus{
+		LastMoveStoppedByCW:  flags[0],
+		LastMoveStoppedByCCW: flags[1],
+		CWActive:             flags[2],
+		CCWActive:            flags[3],
+	}, nil
+}
+
+/*
+Parses the value of the RC response into the closed loop status.
+The lowest bit reports a stall and the next one a homing failure
+('A' is a stall, 'B' an unsuccessful go home)
+*/
+func ParseClosedLoopStatus(response string) (ClosedLoopStatus, error) {
+	flags, err := decodeFlags(response)
+	if err != nil {
+		return ClosedLoopStatus{}, &ParseError{Expected: "closed loop status", Response: response}
+	}
+	return ClosedLoopStatus{
+		Stall:                flags[0],
+		HomingFailed:         flags[1],
+		PostMovePositionLoss: flags[2],
+		StaticPositionLoss:   flags[3],
+	}, nil
+}
+
+/*
+Parses the value of the RB response into the execution status
+*/
+func ParseExecutionStatus(response string) (ExecutionStatus, error) {
+	flags, err := decodeFlags(response)
+	if err != nil {
+		return ExecutionStatus{}, &ParseError{Expected: "execution status", Response: response}
+	}
+	return ExecutionStatus{
+		LoopActive:     flags[0],
+		PauseActive:    flags[1],
+		ShutdownActive: flags[2],
+		TriggerActive:  flags[3],
+	}, nil
+}
+
+/*
+Parses the IS response made of 10 digits for triggers 1-3,
+home, fault, CCW limit, CW limit and sequence select 1-3,
+followed by the device address
+*/
+func ParseInputStatus(response string) (InputStatus, error) {
+	if len(response) != 11 || response[10] < '1' || response[10] > '8' {
+		return InputStatus{}, &ParseError{Expected: "input status", Response: response}
+	}
+	digits, err := decodeDigits(response[:10])
+	if err != nil {
+		return InputStatus{}, &ParseError{Expected: "input status", Response: response}
+	}
+	return InputStatus{
+		Triggers:       [3]bool{digits[0], digits[1], digits[2]},
+		Home:           digits[3],
+		Faulted:        !digits[4],
+		CCWLimit:       digits[5],
+		CWLimit:        digits[6],
+		SequenceSelect: [3]bool{digits[7], digits[8], digits[9]},
+		Address:        uint(response[10] - '0'),
+	}, nil
+}
+
+/*
+Parses the TS response made of 3 digits for triggers 1-3
+*/
+func ParseTriggerStatus(response string) (TriggerStatus, error) {
+	digits, err := decodeDigits(response)
+	if err != nil || len(digits) != 3 {
+		return TriggerStatus{}, &ParseError{Expected: "trigger status", Response: response}
+	}
+	return TriggerStatus{digits[0], digits[1], digits[2]}, nil
+}
+
+/*
+Returns the 4-character string where the first two characters
+represent the last move terminated by CW and CCW limits and the
+last two the current condition of CW and CCW limits
+*/
+func (l LimitStatus) String() string {
+	return formatFlags(l.LastMoveStoppedByCW, l.LastMoveStoppedByCCW, l.CWActive, l.CCWActive)
+}
+
+/*
+Returns true if the last move was terminated by a limit
+*/
+func (l LimitStatus) LastMoveStopped() bool {
+	return l.LastMoveStoppedByCW || l.LastMoveStoppedByCCW
+}
+
+/*
+Returns the 4-character string with the stall, homing failure,
+post move position loss and static position loss flags, in the
+order of the bits of the response ('A' is "1000")
+*/
+func (c ClosedLoopStatus) String() string {
+	return formatFlags(c.Stall, c.HomingFailed, c.PostMovePositionLoss, c.StaticPositionLoss)
+}
+
+/*
+Returns the 4-character string with the loop, pause,
+shutdown and trigger flags
+*/
+func (e ExecutionStatus) String() string {
+	return formatFlags(e.LoopActive, e.PauseActive, e.ShutdownActive, e.TriggerActive)
+}
+
+/*
+Returns the 11-character string in the same format as IS
+*/
+func (i InputStatus) String() string {
+	return formatFlags(
+		i.Triggers[0], i.Triggers[1], i.Triggers[2], i.Home, !i.Faulted,
+		i.CCWLimit, i.CWLimit, i.SequenceSelect[0], i.SequenceSelect[1],
+		i.SequenceSelect[2],
+	) + fmt.Sprint(i.Address)
+}
+
+/*
+Returns the 3-character string in the same format as TS
+*/
+func (t TriggerStatus) String() string {
+	return formatFlags(t[0], t[1], t[2])
+}
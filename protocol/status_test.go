@@ -0,0 +1,176 @@
+package protocol_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+)
+
+/*
+Transport that answers with responses recorded from a drive,
+echoing every command as the OEM750X does
+*/
+type recordedTransport struct {
+	responses map[string]string
+	sent      []string
+	pending   []byte
+}
+
+func (r *recordedTransport) Connect() error    { return nil }
+func (r *recordedTransport) Disconnect() error { return nil }
+func (r *recordedTransport) IsConnected() bool { return true }
+
+func (r *recordedTransport) Read(size uint) ([]byte, error) {
+	n := min(int(size), len(r.pending))
+	data := r.pending[:n]
+	r.pending = r.pending[n:]
+	return data, nil
+}
+
+func (r *recordedTransport) ReadUntil(delimiter string) ([]byte, error) {
+	index := strings.Index(string(r.pending), delimiter)
+	if index < 0 {
+		return nil, fmt.Errorf("read until timeout")
+	}
+	data := r.pending[:index+len(delimiter)]
+	r.pending = r.pending[index+len(delimiter):]
+	return data, nil
+}
+
+func (r *recordedTransport) Write(message []byte) error {
+	command := strings.TrimSuffix(string(message), protocol.CR)
+	r.sent = append(r.sent, command)
+	r.pending = append(r.pending, message...)
+	if response, ok := r.responses[command]; ok {
+		r.pending = append(r.pending, response...)
+	}
+	return nil
+}
+
+func TestStatusReports(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		recorded string
+		read     func(o *protocol.OEM750x) (fmt.Stringer, error)
+		expected string
+	}{
+		{
+			name:     "limits idle",
+			command:  "1RA",
+			recorded: "*@\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.ReadLimitStatus(1) },
+			expected: "0000",
+		},
+		{
+			name:     "limits stopped by CCW with both active",
+			command:  "2RA",
+			recorded: "*N\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.ReadLimitStatus(2) },
+			expected: "0111",
+		},
+		{
+			name:     "closed loop stall",
+			command:  "1RC",
+			recorded: "*A\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.ReadClosedLoopStatus(1) },
+			expected: "1000",
+		},
+		{
+			name:     "closed loop stall and homing failure",
+			command:  "3RC",
+			recorded: "*C\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.ReadClosedLoopStatus(3) },
+			expected: "1100",
+		},
+		{
+			name:     "execution loop",
+			command:  "1RB",
+			recorded: "*A\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.GetExecutionStatus(1) },
+			expected: "1000",
+		},
+		{
+			name:     "execution shutdown and trigger",
+			command:  "1RB",
+			recorded: "*L\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.GetExecutionStatus(1) },
+			expected: "0011",
+		},
+		{
+			name:     "inputs home high",
+			command:  "2IS",
+			recorded: "*00010000002\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.GetInputStatus(2) },
+			expected: "00010000002",
+		},
+		{
+			name:     "inputs with fault line normal",
+			command:  "1IS",
+			recorded: "*10101100001\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.GetInputStatus(1) },
+			expected: "10101100001",
+		},
+		{
+			name:     "triggers",
+			command:  "1TS",
+			recorded: "*101\r",
+			read:     func(o *protocol.OEM750x) (fmt.Stringer, error) { return o.GetTriggerStatus(1) },
+			expected: "101",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := &recordedTransport{
+				responses: map[string]string{test.command: test.recorded},
+			}
+			parker := &protocol.OEM750x{Communication: transport}
+			status, err := test.read(parker)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(transport.sent) != 1 || transport.sent[0] != test.command {
+				t.Fatalf("sent %v, expected %s", transport.sent, test.command)
+			}
+			if status.String() != test.expected {
+				t.Errorf("got %s, expected %s", status, test.expected)
+			}
+		})
+	}
+}
+
+func TestInputStatusFields(t *testing.T) {
+	status, err := protocol.ParseInputStatus("01100010113")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := protocol.InputStatus{
+		Triggers:       [3]bool{false, true, true},
+		Faulted:        true,
+		CWLimit:        true,
+		SequenceSelect: [3]bool{false, true, true},
+		Address:        3,
+	}
+	if status != expected {
+		t.Errorf("got %+v", status)
+	}
+	for _, invalid := range []string{"", "0000000000", "00000000009", "0000000002X1"} {
+		if _, err := protocol.ParseInputStatus(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestStatusStrings(t *testing.T) {
+	transport := &recordedTransport{responses: map[string]string{"1RA": "*E\r", "1RC": "*A\r"}}
+	parker := &protocol.OEM750x{Communication: transport}
+	if limits, err := parker.GetLimitsStatus(1); err != nil || limits != "1010" {
+		t.Fatalf("limits status = %q, %v", limits, err)
+	}
+	if closedLoop, err := parker.GetClosedLoopStatus(1); err != nil || closedLoop != "1000" {
+		t.Fatalf("closed loop status = %q, %v", closedLoop, err)
+	}
+}
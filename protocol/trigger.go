@@ -0,0 +1,121 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+/*
+How a move waiting for the trigger inputs ended
+*/
+type TriggerResult uint
+
+const (
+	TriggerStarted TriggerResult = iota
+	TriggerAborted
+)
+
+func (r TriggerResult) String() string {
+	switch r {
+	case TriggerStarted:
+		return "started"
+	case TriggerAborted:
+		return "aborted"
+	}
+	return fmt.Sprintf("unknown (%d)", uint(r))
+}
+
+/*
+Moves the motor with the current settings when the trigger
+inputs match the pattern, where each character is '1' (high),
+'0' (low) or 'X' (ignored). The wait for trigger (TR) and the
+go command are buffered in the drive, which starts the move
+on the trigger, and the host only polls the relative position
+(W3) to report it. Returns TriggerStarted as soon as the move
+began, without waiting for it to finish. When the indexer
+becomes ready with the absolute position unchanged, e.g. as
+the buffered commands were cleared, the move never started
+and the result is TriggerAborted with ErrNotStarted. The
+absolute position is read first, so the indexer must be ready
+
+When the context is done before the trigger, the motor is
+stopped (S), which clears the buffered commands, and the
+result is TriggerAborted with the context error. A move that
+started at the same time is stopped too, and is reported as
+TriggerStarted with a MoveError
+*/
+func (o *OEM750x) GoOnTrigger(ctx context.Context, channel uint, pattern string) (TriggerResult, error) {
+	if !validPattern(pattern, 3) {
+		return TriggerAborted, fmt.Errorf("%w: trigger pattern must have up to 3 characters of 1, 0 or X, got %q", ErrInvalidParameter, pattern)
+	}
+	watch, err := o.checkSoftLimits(ctx, channel)
+	if err != nil {
+		return TriggerAborted, err
+	}
+	start, err := o.GetAbsolutePosition(channel)
+	if err != nil {
+		return TriggerAborted, err
+	}
+	initial, err := o.GetRelativePosition(channel)
+	if err != nil {
+		return TriggerAborted, err
+	}
+	msg := fmt.Sprintf("%dTR%s", channel, pattern)
+	if err := o.Write(msg); err != nil {
+		return TriggerAborted, err
+	}
+	if err := o.sendGo(channel); err != nil {
+		return TriggerAborted, errors.Join(err, o.Stop(channel))
+	}
+	if watch != nil {
+		o.startWatch(watch)
+	}
+
+	ticker := time.NewTicker(o.pollInterval())
+	defer ticker.Stop()
+	for {
+		status, err := o.GetIndexerStatus(channel)
+		if err != nil {
+			return TriggerAborted, err
+		}
+		switch status {
+		case IndexerReady:
+			position, err := o.GetAbsolutePosition(channel)
+			if err != nil {
+				return TriggerAborted, err
+			}
+			if position == start {
+				return TriggerAborted, &MoveError{Channel: channel, Status: status, Position: position, Err: ErrNotStarted}
+			}
+			return TriggerStarted, nil
+		case IndexerReadyAttention, IndexerBusyAttention:
+			return TriggerStarted, o.attentionError(channel, status)
+		}
+		relative, err := o.GetRelativePosition(channel)
+		if err != nil {
+			return TriggerAborted, err
+		}
+		if relative != initial {
+			return TriggerStarted, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := o.Stop(channel); err != nil {
+				return TriggerAborted, errors.Join(ctx.Err(), err)
+			}
+			relative, err := o.GetRelativePosition(channel)
+			if err != nil || relative == initial {
+				return TriggerAborted, ctx.Err()
+			}
+			moveErr := &MoveError{Channel: channel, Status: status, Err: ctx.Err()}
+			if position, err := o.stoppedPosition(channel); err == nil {
+				moveErr.Position = position
+			}
+			return TriggerStarted, moveErr
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package protocol_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+func TestGoOnTrigger(t *testing.T) {
+	sim := simulator.New(simulator.Options{})
+	parker := newFastAxis(t, sim)
+	if err := parker.SetNormalMode(1); err != nil {
+		t.Fatal(err)
+	} else if err := parker.SetTargetDistance(1, 400); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		sim.SetTriggers(1, [3]bool{true, false, false})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := parker.GoOnTrigger(ctx, 1, "1")
+	if err != nil || result != protocol.TriggerStarted {
+		t.Fatalf("GoOnTrigger = %s, %v", result, err)
+	}
+	if err := parker.WaitForMove(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if position := sim.Position(1); position != 400 {
+		t.Fatalf("position after the triggered move = %d", position)
+	}
+
+	wait, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	result, err = parker.GoOnTrigger(wait, 1, "XX1")
+	if result != protocol.TriggerAborted || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GoOnTrigger without trigger = %s, %v", result, err)
+	}
+	sim.SetTriggers(1, [3]bool{false, false, true})
+	if position := sim.Position(1); position != 400 || sim.IsMoving(1) {
+		t.Fatalf("aborted move went to %d", position)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		parker.Stop(1)
+	}()
+	result, err = parker.GoOnTrigger(ctx, 1, "1")
+	if result != protocol.TriggerAborted || !errors.Is(err, protocol.ErrNotStarted) {
+		t.Fatalf("GoOnTrigger cleared before the trigger = %s, %v", result, err)
+	}
+	if position := sim.Position(1); position != 400 || sim.IsMoving(1) {
+		t.Fatalf("cleared move went to %d", position)
+	}
+}
@@ -0,0 +1,80 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+/*
+Operation of the communication captured in a frame
+*/
+type Operation string
+
+const (
+	Write     Operation = "write"
+	Read      Operation = "read"
+	ReadUntil Operation = "read_until"
+)
+
+/*
+A single exchange with the communication. The frames are
+stored one per line as JSON, where the CR of the messages
+is escaped, so a session can be read and edited by hand
+*/
+type Frame struct {
+	Time      time.Time `json:"time"`
+	Operation Operation `json:"op"`
+	Data      string    `json:"data"`
+	Error     string    `json:"error,omitempty"`
+}
+
+/*
+Returns true if the frame was read from the device
+*/
+func (f Frame) IsRead() bool {
+	return f.Operation == Read || f.Operation == ReadUntil
+}
+
+/*
+Decodes the frames of a session, one JSON object per line
+*/
+func ReadFrames(r io.Reader) ([]Frame, error) {
+	var frames []Frame
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch frame.Operation {
+		case Write, Read, ReadUntil:
+		default:
+			return nil, fmt.Errorf("line %d: unknown operation %q", line, frame.Operation)
+		}
+		frames = append(frames, frame)
+	}
+	return frames, scanner.Err()
+}
+
+/*
+Loads the frames of a session file
+*/
+func Load(path string) ([]Frame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	frames, err := ReadFrames(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return frames, nil
+}
@@ -0,0 +1,191 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+/*
+Error returned when the exchange differs from the session,
+such as a command that was not recorded or a read where
+the session has a write
+*/
+type DivergenceError struct {
+	Index    int
+	Expected *Frame
+	Got      Operation
+	Data     string
+}
+
+func (e *DivergenceError) Error() string {
+	if e.Expected == nil {
+		return fmt.Sprintf("frame %d: %s %q after the end of the session", e.Index, e.Got, e.Data)
+	}
+	if e.Got == Write {
+		return fmt.Sprintf("frame %d: wrote %q, session has %s %q", e.Index, e.Data, e.Expected.Operation, e.Expected.Data)
+	}
+	return fmt.Sprintf("frame %d: %s, session has %s %q", e.Index, e.Got, e.Expected.Operation, e.Expected.Data)
+}
+
+/*
+Communication that serves the responses of a recorded
+session, checking that the commands are written in the
+same order. Any divergence fails the test, and so do the
+frames that were not replayed when the test ends
+*/
+type Player struct {
+	t         testing.TB
+	frames    []Frame
+	next      int
+	diverged  *DivergenceError
+	connected bool
+	mutex     sync.Mutex
+}
+
+/*
+Creates a player of the frames bound to the test
+*/
+func NewPlayer(t testing.TB, frames []Frame) *Player {
+	p := &Player{t: t, frames: frames}
+	t.Cleanup(func() {
+		if remaining := p.Remaining(); remaining > 0 {
+			t.Errorf("replay: %d of %d frames were not replayed", remaining, len(p.frames))
+		}
+	})
+	return p
+}
+
+/*
+Creates a player of the session file bound to the test,
+failing the test if the file cannot be loaded
+*/
+func Open(t testing.TB, path string) *Player {
+	t.Helper()
+	frames, err := Load(path)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	return NewPlayer(t, frames)
+}
+
+/*
+Returns the number of frames not replayed yet
+*/
+func (p *Player) Remaining() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.frames) - p.next
+}
+
+/*
+Establishes the replayed connection
+*/
+func (p *Player) Connect() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.connected {
+		return fmt.Errorf("there is a port already connected")
+	}
+	p.connected = true
+	return nil
+}
+
+/*
+Closes the replayed connection
+*/
+func (p *Player) Disconnect() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.connected {
+		return fmt.Errorf("there is no port connected")
+	}
+	p.connected = false
+	return nil
+}
+
+/*
+Returns true if the replayed connection is open
+*/
+func (p *Player) IsConnected() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.connected
+}
+
+/*
+Returns the bytes of the next recorded read
+*/
+func (p *Player) Read(size uint) ([]byte, error) {
+	return p.read(Read)
+}
+
+/*
+Returns the bytes of the next recorded read, with the
+recorded error when it timed out
+*/
+func (p *Player) ReadUntil(delimiter string) ([]byte, error) {
+	return p.read(ReadUntil)
+}
+
+/*
+Checks that the message is the next recorded write
+*/
+func (p *Player) Write(message []byte) error {
+	frame, err := p.advance(Write, string(message), func(frame Frame) bool {
+		return frame.Operation == Write && frame.Data == string(message)
+	})
+	if err != nil {
+		return err
+	}
+	return frameError(frame)
+}
+
+func (p *Player) read(operation Operation) ([]byte, error) {
+	frame, err := p.advance(operation, "", Frame.IsRead)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(frame.Data), frameError(frame)
+}
+
+/*
+Moves to the next frame if it matches, otherwise fails
+the test. The stream is no longer aligned after that, so
+the following calls return the same error
+*/
+func (p *Player) advance(operation Operation, data string, matches func(Frame) bool) (Frame, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.connected {
+		return Frame{}, fmt.Errorf("there is no port connected")
+	}
+	if p.diverged != nil {
+		return Frame{}, p.diverged
+	}
+	divergence := &DivergenceError{Index: p.next, Got: operation, Data: data}
+	if p.next < len(p.frames) {
+		frame := p.frames[p.next]
+		if matches(frame) {
+			p.next++
+			return frame, nil
+		}
+		divergence.Expected = &frame
+	}
+	p.diverged = divergence
+	p.t.Errorf("replay: %v", divergence)
+	return Frame{}, divergence
+}
+
+func frameError(frame Frame) error {
+	if frame.Error == "" {
+		return nil
+	}
+	return errors.New(frame.Error)
+}
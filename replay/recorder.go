@@ -0,0 +1,134 @@
+package replay
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/devicehub-go/unicomm"
+)
+
+/*
+Communication that forwards every call to another one and
+logs the written and read frames with their timestamps
+*/
+type Recorder struct {
+	communication unicomm.Unicomm
+	encoder       *json.Encoder
+	closer        io.Closer
+	clock         func() time.Time
+	err           error
+	mutex         sync.Mutex
+}
+
+/*
+Creates a recorder that writes the frames exchanged with
+the communication to the output, one JSON object per line
+*/
+func NewRecorder(communication unicomm.Unicomm, output io.Writer) *Recorder {
+	return &Recorder{
+		communication: communication,
+		encoder:       json.NewEncoder(output),
+		clock:         time.Now,
+	}
+}
+
+/*
+Creates a recorder that writes the session to the file,
+replacing it if it exists. Close must be called to
+release the file
+*/
+func Record(communication unicomm.Unicomm, path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	recorder := NewRecorder(communication, file)
+	recorder.closer = file
+	return recorder, nil
+}
+
+/*
+Closes the session file, returning the first error found
+while writing the frames
+*/
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closer != nil {
+		if err := r.closer.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+		r.closer = nil
+	}
+	return r.err
+}
+
+/*
+Logs a frame, keeping the first error so the exchanges
+with the device are not affected by the recording
+*/
+func (r *Recorder) log(operation Operation, data []byte, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	frame := Frame{Time: r.clock(), Operation: operation, Data: string(data)}
+	if err != nil {
+		frame.Error = err.Error()
+	}
+	if encodeErr := r.encoder.Encode(frame); encodeErr != nil && r.err == nil {
+		r.err = encodeErr
+	}
+}
+
+/*
+Establishes the connection of the recorded communication
+*/
+func (r *Recorder) Connect() error {
+	return r.communication.Connect()
+}
+
+/*
+Closes the connection of the recorded communication
+*/
+func (r *Recorder) Disconnect() error {
+	return r.communication.Disconnect()
+}
+
+/*
+Returns true if the recorded communication is connected
+*/
+func (r *Recorder) IsConnected() bool {
+	return r.communication.IsConnected()
+}
+
+/*
+Reads up to size bytes and logs them
+*/
+func (r *Recorder) Read(size uint) ([]byte, error) {
+	data, err := r.communication.Read(size)
+	r.log(Read, data, err)
+	return data, err
+}
+
+/*
+Reads until the delimiter and logs the bytes read,
+including the partial ones of a timeout
+*/
+func (r *Recorder) ReadUntil(delimiter string) ([]byte, error) {
+	data, err := r.communication.ReadUntil(delimiter)
+	r.log(ReadUntil, data, err)
+	return data, err
+}
+
+/*
+Writes the message and logs it
+*/
+func (r *Recorder) Write(message []byte) error {
+	err := r.communication.Write(message)
+	r.log(Write, message, err)
+	return err
+}
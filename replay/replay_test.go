@@ -0,0 +1,181 @@
+package replay_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/replay"
+	"github.com/devicehub-go/unicomm"
+	"github.com/devicehub-go/unicomm/protocol/unicommserial"
+)
+
+/*
+The sessions of testdata are replayed by default. They are
+recorded again from the drives connected to a serial port
+with: go test ./replay -record /dev/ttyUSB0
+*/
+var record = flag.String("record", "", "serial port of the drives to record the sessions from")
+
+/*
+Connects to the replayed session, or to the drives while
+recording it
+*/
+func session(t *testing.T, name string) *protocol.OEM750x {
+	path := filepath.Join("testdata", name+".jsonl")
+	var communication unicomm.Unicomm
+	if *record == "" {
+		communication = replay.Open(t, path)
+	} else {
+		recorder, err := replay.Record(unicomm.New(unicomm.Options{
+			Protocol:  unicomm.Serial,
+			Delimiter: protocol.CR,
+			Serial: unicommserial.SerialOptions{
+				PortName: *record,
+				BaudRate: 9600,
+				DataBits: 8,
+				StopBits: unicommserial.OneStopBit,
+				Parity:   unicommserial.NoParity,
+			},
+		}), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			if err := recorder.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+		communication = recorder
+	}
+
+	parker := oem750x.NewWithCommunication(communication)
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { parker.Disconnect() })
+	return parker
+}
+
+func TestReadings(t *testing.T) {
+	parker := session(t, "readings")
+
+	var channel uint = 4
+	info, err := parker.GetDriveInfo(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexerStatus, err := parker.GetIndexerStatus(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedLoopStatus, err := parker.GetClosedLoopStatus(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limitStatus, err := parker.GetLimitsStatus(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	absolute, err := parker.GetAbsolutePosition(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative, err := parker.GetRelativePosition(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("part number %s%s, indexer %s, closed loop %s, limits %s, position %d, relative %d",
+		info.PartNumber, info.Revision, indexerStatus, closedLoopStatus, limitStatus, absolute, relative)
+}
+
+func TestHoming(t *testing.T) {
+	parker := session(t, "homing")
+
+	var channel uint = 3
+	if err := parker.SetNormalMode(channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetDisableSwitch(channel, protocol.EnableBoth); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetEndLimitsState(channel, protocol.NormallyOpen); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetResolution(channel, 50000); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetPolarity(channel, protocol.Inverted); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetDirection(channel, protocol.Forward); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := parker.GoHomeHard(ctx, channel, 0.8); err != nil {
+		t.Fatal(err)
+	}
+	if position, err := parker.GetAbsolutePosition(channel); err != nil || position != 0 {
+		t.Fatalf("absolute position after homing = %d, %v", position, err)
+	}
+}
+
+/*
+Test that collects the failures reported by the player
+*/
+type failures struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *failures) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *failures) Cleanup(cleanup func()) {
+	f.cleanups = append(f.cleanups, cleanup)
+}
+
+func TestDivergence(t *testing.T) {
+	frames, err := replay.ReadFrames(strings.NewReader(
+		`{"time":"2025-11-26T10:00:00Z","op":"write","data":"1V\r"}` + "\n" +
+			`{"time":"2025-11-26T10:00:00Z","op":"read_until","data":"1V\r"}` + "\n" +
+			`{"time":"2025-11-26T10:00:00Z","op":"read_until","data":"*V1.00\r"}` + "\n" +
+			`{"time":"2025-11-26T10:00:00Z","op":"write","data":"1A\r"}` + "\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &failures{TB: t}
+	parker := oem750x.NewWithCommunication(replay.NewPlayer(f, frames))
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if velocity, err := parker.GetTargetVelocity(1); err != nil || velocity != 1 || len(f.errors) != 0 {
+		t.Fatalf("velocity = %g, %v, %v", velocity, err, f.errors)
+	}
+
+	var divergence *replay.DivergenceError
+	if _, err := parker.GetTargetVelocity(2); !errors.As(err, &divergence) || divergence.Index != 3 {
+		t.Fatalf("expected a divergence at frame 3, got %v", err)
+	}
+	if len(f.errors) != 1 {
+		t.Fatalf("expected the divergence to fail the test, got %v", f.errors)
+	}
+	for _, cleanup := range f.cleanups {
+		cleanup()
+	}
+	if len(f.errors) != 2 || !strings.Contains(f.errors[1], "1 of 4 frames") {
+		t.Fatalf("expected the frames left to fail the test, got %v", f.errors)
+	}
+}
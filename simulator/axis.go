@@ -0,0 +1,492 @@
+package simulator
+
+import (
+	"math"
+	"time"
+)
+
+/*
+Motion segment being executed by a simulated axis. The profile
+starts at the initial velocity, accelerates up to the target
+velocity, cruises and then decelerates to a stop after travelling
+the requested distance
+*/
+type motion struct {
+	start      time.Time
+	origin     float64
+	direction  float64
+	distance   float64
+	initial    float64
+	velocity   float64
+	accel      float64
+	continuous bool
+	homing     bool
+}
+
+/*
+Returns the duration of each phase of the motion profile
+and the peak velocity reached
+*/
+func (m *motion) phases() (float64, float64, float64, float64) {
+	peak := m.velocity
+	if m.continuous {
+		return (peak - m.initial) / m.accel, math.Inf(1), 0, peak
+	}
+	accelDist := (peak*peak - m.initial*m.initial) / (2 * m.accel)
+	decelDist := peak * peak / (2 * m.accel)
+	if accelDist+decelDist > m.distance {
+		peak = math.Sqrt((2*m.accel*m.distance + m.initial*m.initial) / 2)
+		if peak < m.initial {
+			peak = m.initial
+		}
+		accelDist = (peak*peak - m.initial*m.initial) / (2 * m.accel)
+		decelDist = m.distance - accelDist
+	}
+	cruise := 0.0
+	if peak > 0 {
+		cruise = (m.distance - accelDist - decelDist) / peak
+	}
+	return (peak - m.initial) / m.accel, cruise, peak / m.accel, peak
+}
+
+/*
+Returns the duration of the motion profile
+*/
+func (m *motion) duration() time.Duration {
+	ta, tc, td, _ := m.phases()
+	return time.Duration((ta + tc + td) * float64(time.Second))
+}
+
+/*
+Returns the distance travelled and the velocity at the
+elapsed time, and true when the motion is finished
+*/
+func (m *motion) at(elapsed float64) (float64, float64, bool) {
+	ta, tc, td, peak := m.phases()
+	if elapsed < ta {
+		v := m.initial + m.accel*elapsed
+		return (m.initial + v) / 2 * elapsed, v, false
+	}
+	travelled := (m.initial + peak) / 2 * ta
+	elapsed -= ta
+	if elapsed < tc {
+		return travelled + peak*elapsed, peak, false
+	}
+	travelled += peak * tc
+	elapsed -= tc
+	if elapsed < td {
+		v := peak - m.accel*elapsed
+		return travelled + (peak+v)/2*elapsed, v, false
+	}
+	return m.distance, 0, true
+}
+
+/*
+Simulated state of a single drive on the daisy chain
+*/
+type axis struct {
+	address    uint
+	velocity   float64
+	accel      float64
+	distance   int
+	resolution int
+	continuous bool
+	absolute   bool
+	direction  float64
+	polarity   int
+	shutdown   bool
+	limits     int
+	position   float64
+	moveOrigin float64
+	motion     *motion
+
+	attention   bool
+	stoppedCW   bool
+	stoppedCCW  bool
+	homeFailed  bool
+	forcedCW    bool
+	forcedCCW   bool
+	travelMin   float64
+	travelMax   float64
+	hasTravel   bool
+	homePos     float64
+	hasHome     bool
+	homeReverse bool
+	backUpHome  bool
+	homeIndex   bool
+	indexPos    float64
+	hasIndex    bool
+
+	hardMin      float64
+	hardMax      float64
+	hasHardStops bool
+	encoderSteps bool
+	stallDetect  bool
+	stopOnStall  bool
+
+	encoderResolution   int
+	encoderSlip         float64
+	positionMaintenance bool
+	deadBand            int
+	deadBandWindow      int
+	correctionGain      int
+
+	triggers     [3]bool
+	stall        bool
+	postMoveLoss bool
+	staticLoss   bool
+
+	program program
+	outputs [2]bool
+
+	errorChecking bool
+	framingError  bool
+}
+
+/*
+Creates an axis with the power-up values of the indexer
+*/
+func newAxis(address uint) *axis {
+	a := &axis{address: address}
+	a.reset()
+	return a
+}
+
+/*
+Returns all settings to their power-up values
+*/
+func (a *axis) reset() {
+	a.velocity = 1
+	a.accel = 10
+	a.distance = 25000
+	a.resolution = 25000
+	a.continuous = false
+	a.absolute = false
+	a.direction = 1
+	a.polarity = 1
+	a.shutdown = false
+	a.limits = 0
+	a.position = 0
+	a.moveOrigin = 0
+	a.motion = nil
+	a.attention = false
+	a.stoppedCW = false
+	a.stoppedCCW = false
+	a.homeFailed = false
+	a.stall = false
+	a.postMoveLoss = false
+	a.staticLoss = false
+	a.errorChecking = false
+	a.backUpHome = false
+	a.homeIndex = false
+	a.encoderSteps = false
+	a.stallDetect = false
+	a.stopOnStall = false
+	a.encoderResolution = 4000
+	a.encoderSlip = 0
+	a.positionMaintenance = false
+	a.deadBand = 0
+	a.deadBandWindow = 0
+	a.correctionGain = 8
+}
+
+/*
+Returns the position of the encoder in encoder steps, that
+follows the motor unless a slip was injected
+*/
+func (a *axis) encoderPosition() int {
+	position := a.position
+	if !a.encoderSteps {
+		position = position * float64(a.encoderResolution) / float64(a.resolution)
+	}
+	return int(math.Round(position + a.encoderSlip))
+}
+
+/*
+Returns the position where the go home procedure ends, that
+is the encoder Z channel pulse when it is referenced
+*/
+func (a *axis) homeTarget() float64 {
+	if a.homeIndex && a.backUpHome && a.encoderSteps && a.hasIndex {
+		return a.indexPos
+	}
+	return a.homePos
+}
+
+/*
+Returns true if the positive direction moves the motor
+clockwise according to the commanded direction polarity
+*/
+func (a *axis) positiveIsCW() bool {
+	return a.polarity == 1
+}
+
+/*
+Starts a new motion segment from the current position
+*/
+func (a *axis) begin(now time.Time, m *motion) {
+	m.start = now
+	m.origin = a.position
+	a.moveOrigin = a.position
+	a.attention = false
+	a.stoppedCW = false
+	a.stoppedCCW = false
+	a.stall = false
+	a.postMoveLoss = false
+	if !m.continuous && m.distance <= 0 {
+		a.motion = nil
+		return
+	}
+	a.motion = m
+}
+
+/*
+Executes a go command with the current settings
+*/
+func (a *axis) start(now time.Time) {
+	if a.shutdown || a.motion != nil {
+		return
+	}
+	m := &motion{
+		velocity: a.velocity * float64(a.resolution),
+		accel:    a.accel * float64(a.resolution),
+	}
+	if a.continuous {
+		m.continuous = true
+		m.direction = a.direction
+	} else if a.absolute {
+		delta := float64(a.distance) - a.position
+		m.direction = math.Copysign(1, delta)
+		m.distance = math.Abs(delta)
+	} else {
+		m.direction = a.direction
+		m.distance = math.Abs(float64(a.distance))
+	}
+	if a.blockedBy(m.direction) {
+		a.markLimit(m.direction)
+		return
+	}
+	a.begin(now, m)
+}
+
+/*
+Executes the go home procedure in the given direction
+and velocity in rps
+*/
+func (a *axis) home(now time.Time, direction float64, speed float64) {
+	if a.shutdown || a.motion != nil {
+		return
+	}
+	a.homeFailed = false
+	a.homeReverse = false
+	m := &motion{
+		direction:  direction,
+		velocity:   speed * float64(a.resolution),
+		accel:      a.accel * float64(a.resolution),
+		continuous: true,
+		homing:     true,
+	}
+	if a.hasHome && math.Copysign(1, a.homeTarget()-a.position) == direction {
+		m.continuous = false
+		m.distance = math.Abs(a.homeTarget() - a.position)
+	}
+	a.begin(now, m)
+}
+
+/*
+Decelerates the motor to a stop using the current acceleration
+*/
+func (a *axis) stop(now time.Time) {
+	a.advance(now)
+	if a.motion == nil {
+		return
+	}
+	elapsed := now.Sub(a.motion.start).Seconds()
+	_, v, _ := a.motion.at(elapsed)
+	homing := a.motion.homing
+	direction := a.motion.direction
+	a.motion = &motion{
+		start:     now,
+		origin:    a.position,
+		direction: direction,
+		distance:  v * v / (2 * a.motion.accel),
+		initial:   v,
+		velocity:  v,
+		accel:     a.motion.accel,
+	}
+	if homing {
+		a.homeFailed = true
+	}
+	if a.motion.distance <= 0 {
+		a.motion = nil
+	}
+}
+
+/*
+Ceases the motion immediately
+*/
+func (a *axis) kill(now time.Time) {
+	a.advance(now)
+	if a.motion != nil && a.motion.homing {
+		a.homeFailed = true
+	}
+	a.motion = nil
+}
+
+/*
+Returns true if an enabled end-of-travel limit is active
+in the given direction
+*/
+func (a *axis) blockedBy(direction float64) bool {
+	cw, ccw := a.limitInputs()
+	if (direction > 0) == a.positiveIsCW() {
+		return cw && a.limits&1 == 0
+	}
+	return ccw && a.limits&2 == 0
+}
+
+/*
+Records that the last move was terminated by the limit
+in the given direction
+*/
+func (a *axis) markLimit(direction float64) {
+	a.program.abort(true)
+	a.attention = true
+	if (direction > 0) == a.positiveIsCW() {
+		a.stoppedCW = true
+	} else {
+		a.stoppedCCW = true
+	}
+}
+
+/*
+Updates the axis position up to the given instant, stopping
+the motion when a limit or the home position is reached
+*/
+func (a *axis) advance(now time.Time) {
+	if a.motion == nil {
+		return
+	}
+	m := a.motion
+	travelled, _, done := m.at(now.Sub(m.start).Seconds())
+	a.position = m.origin + m.direction*travelled
+
+	if a.hasHardStops {
+		bound := a.hardMax
+		if m.direction < 0 {
+			bound = a.hardMin
+		}
+		if (m.direction > 0 && a.position >= bound) || (m.direction < 0 && a.position <= bound) {
+			a.position = bound
+			detected := a.stallDetect && a.encoderSteps
+			if detected {
+				a.stall = true
+			}
+			if done || (detected && a.stopOnStall) {
+				a.motion = nil
+				a.program.ready = now
+			}
+			if detected && a.stopOnStall {
+				a.program.abort(true)
+				if m.homing {
+					a.homeFailed = true
+				}
+			}
+			return
+		}
+	}
+
+	if a.hasTravel {
+		bound := a.travelMax
+		if m.direction < 0 {
+			bound = a.travelMin
+		}
+		crossed := (m.direction > 0 && a.position >= bound) ||
+			(m.direction < 0 && a.position <= bound)
+		if crossed && a.blockedBy(m.direction) {
+			a.position = bound
+			a.motion = nil
+			if m.homing && a.hasHome && !a.homeReverse {
+				a.homeReverse = true
+				reverse := &motion{
+					direction: -m.direction,
+					distance:  math.Abs(a.homeTarget() - a.position),
+					velocity:  m.velocity,
+					accel:     m.accel,
+					homing:    true,
+				}
+				reverse.start = now
+				reverse.origin = a.position
+				a.motion = reverse
+				return
+			}
+			a.program.ready = now
+			a.markLimit(m.direction)
+			if m.homing {
+				a.homeFailed = true
+			}
+			return
+		}
+	}
+	if done {
+		a.position = m.origin + m.direction*m.distance
+		a.motion = nil
+		a.program.ready = m.start.Add(m.duration())
+	}
+}
+
+/*
+Returns the current state of the CW and CCW limit inputs
+*/
+func (a *axis) limitInputs() (bool, bool) {
+	cw, ccw := a.forcedCW, a.forcedCCW
+	if a.hasTravel {
+		atMax := a.position >= a.travelMax
+		atMin := a.position <= a.travelMin
+		if a.positiveIsCW() {
+			cw, ccw = cw || atMax, ccw || atMin
+		} else {
+			cw, ccw = cw || atMin, ccw || atMax
+		}
+	}
+	return cw, ccw
+}
+
+/*
+Encodes four flags as the ASCII bit-mask used by the
+RA, RB and RC status reports
+*/
+func encodeFlags(flags ...bool) string {
+	value := byte('@')
+	for i, flag := range flags {
+		if flag {
+			value |= 1 << i
+		}
+	}
+	return string(value)
+}
+
+/*
+Returns true if at least one trigger input is active
+*/
+func (a *axis) triggerActive() bool {
+	return a.triggers[0] || a.triggers[1] || a.triggers[2]
+}
+
+/*
+Returns true while the motor is over the home switch
+*/
+func (a *axis) homeActive() bool {
+	return a.hasHome && math.Abs(a.position-a.homePos) < 0.5
+}
+
+/*
+Returns the hardware input levels in the format reported by IS
+*/
+func (a *axis) inputStatus() string {
+	cw, ccw := a.limitInputs()
+	return formatDigits(
+		a.triggers[0], a.triggers[1], a.triggers[2], a.homeActive(), true,
+		ccw, cw, false, false, false,
+	) + string(byte('0'+a.address%10))
+}
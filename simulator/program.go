@@ -0,0 +1,305 @@
+package simulator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sequenceCount  int = 7
+	sequenceMemory int = 2048
+	maxSteps       int = 10000
+)
+
+/*
+Loop being executed by the command buffer, where a zero
+count loops indefinitely
+*/
+type loop struct {
+	start     int
+	remaining int
+}
+
+/*
+Stored sequences and command buffer of a simulated drive.
+Sequences survive a reset as they are kept in BBRAM
+*/
+type program struct {
+	sequences    [sequenceCount + 1]string
+	defining     int
+	definition   []string
+	defineStatus int
+	runStatus    int
+	buffer       []string
+	pc           int
+	loops        []loop
+	ready        time.Time
+	paused       bool
+}
+
+/*
+Returns true while the command buffer has pending commands
+*/
+func (p *program) running() bool {
+	return p.pc < len(p.buffer)
+}
+
+/*
+Clears the command buffer, reporting the running sequence
+as stopped when aborted by a stop, kill or limit
+*/
+func (p *program) abort(stopped bool) {
+	if p.buffer != nil && p.runStatus == 5 && stopped {
+		p.runStatus = 6
+	}
+	p.buffer = nil
+	p.pc = 0
+	p.loops = nil
+	p.paused = false
+}
+
+/*
+Returns the number of bytes used by the stored sequences
+*/
+func (p *program) memory() int {
+	used := 0
+	for _, sequence := range p.sequences {
+		used += len(sequence)
+	}
+	return used
+}
+
+/*
+Returns the checksum of the sequence memory reported by XC
+*/
+func (p *program) checksum() int {
+	sum := 0
+	for _, sequence := range p.sequences {
+		for _, b := range []byte(sequence) {
+			sum += int(b)
+		}
+	}
+	return sum % 256
+}
+
+/*
+Parses the number of a sequence between 1 and 7
+*/
+func sequenceNumber(argument string) (int, bool) {
+	n, err := strconv.Atoi(argument)
+	return n, err == nil && 1 <= n && n <= sequenceCount
+}
+
+/*
+Returns true if the command waits in the command buffer
+instead of executing immediately
+*/
+func waits(mnemonic string) bool {
+	switch mnemonic {
+	case "T", "TR", "L", "N", "PS":
+		return true
+	}
+	return false
+}
+
+/*
+Returns the response of the sequence status commands
+*/
+func (s *Simulator) reportProgram(a *axis, mnemonic string, argument string) (string, bool) {
+	switch mnemonic {
+	case "XSD":
+		return fmt.Sprintf("*%d", a.program.defineStatus), argument == ""
+	case "XSR":
+		status := a.program.runStatus
+		if status == 5 && len(a.program.loops) > 0 {
+			status = 1
+		}
+		return fmt.Sprintf("*%d", status), argument == ""
+	case "XC":
+		return fmt.Sprintf("*%d", a.program.checksum()), argument == ""
+	case "XSS":
+		if n, ok := sequenceNumber(argument); ok {
+			if a.program.sequences[n] == "" {
+				return "*0", true
+			}
+			return "*3", true
+		}
+	case "XU":
+		if n, ok := sequenceNumber(argument); ok {
+			return "*" + a.program.sequences[n], true
+		}
+	}
+	return "", false
+}
+
+/*
+Applies the sequence programming commands and returns
+false when the mnemonic is not one of them
+*/
+func (s *Simulator) applyProgram(a *axis, mnemonic string, argument string, now time.Time) bool {
+	p := &a.program
+	switch mnemonic {
+	case "XD":
+		if n, ok := sequenceNumber(argument); ok {
+			p.defining = n
+			p.definition = nil
+		}
+	case "XT":
+		if p.defining == 0 {
+			break
+		}
+		contents := strings.Join(p.definition, " ")
+		switch {
+		case p.sequences[p.defining] != "":
+			p.defineStatus = 1
+		case p.memory()+len(contents) > sequenceMemory:
+			p.defineStatus = 2
+		default:
+			p.sequences[p.defining] = contents
+			p.defineStatus = 0
+		}
+		p.defining = 0
+		p.definition = nil
+	case "XE":
+		if n, ok := sequenceNumber(argument); ok {
+			p.sequences[n] = ""
+		}
+	case "XR", "XRP":
+		n, ok := sequenceNumber(argument)
+		if !ok {
+			break
+		}
+		if p.sequences[n] == "" {
+			p.abort(false)
+			p.runStatus = 2
+			break
+		}
+		p.abort(false)
+		p.buffer = strings.Fields(p.sequences[n])
+		p.ready = now
+		p.paused = mnemonic == "XRP"
+		p.runStatus = 5
+	case "C":
+		p.paused = false
+	case "Y":
+		for i := range p.loops {
+			p.loops[i].remaining = 1
+		}
+	case "O":
+		if strings.Trim(argument, "01X") != "" {
+			break
+		}
+		for i := 0; i < len(argument) && i < len(a.outputs); i++ {
+			if argument[i] != 'X' {
+				a.outputs[i] = argument[i] == '1'
+			}
+		}
+		if argument == "" {
+			a.outputs = [2]bool{}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+/*
+Appends a command to the command buffer, starting the
+buffer when it was empty
+*/
+func (a *axis) enqueue(command string, now time.Time) {
+	p := &a.program
+	if !p.running() {
+		p.buffer = nil
+		p.pc = 0
+		p.ready = now
+	}
+	p.buffer = append(p.buffer, command)
+}
+
+/*
+Advances the motion and executes the command buffer up to the
+given instant. Each command runs when the previous move or
+delay has finished, so that the timing does not depend on
+how often the simulator is polled
+*/
+func (s *Simulator) step(a *axis, now time.Time) {
+	a.advance(now)
+	p := &a.program
+	for steps := 0; steps < maxSteps && p.running(); steps++ {
+		if a.motion != nil || p.paused || p.ready.After(now) {
+			return
+		}
+		_, mnemonic, argument := parseCommand(p.buffer[p.pc])
+		at := p.ready
+		switch mnemonic {
+		case "T":
+			seconds, err := strconv.ParseFloat(argument, 64)
+			if err == nil {
+				p.ready = at.Add(time.Duration(seconds * float64(time.Second)))
+			}
+		case "TR":
+			if !a.triggersMatch(argument) {
+				return
+			}
+			p.ready = now
+		case "L":
+			count, err := strconv.Atoi(argument)
+			if err != nil || count < 0 {
+				count = 1
+			}
+			p.loops = append(p.loops, loop{start: p.pc + 1, remaining: count})
+		case "N":
+			if len(p.loops) > 0 {
+				top := &p.loops[len(p.loops)-1]
+				if top.remaining != 1 {
+					if top.remaining > 1 {
+						top.remaining--
+					}
+					p.pc = top.start
+					continue
+				}
+				p.loops = p.loops[:len(p.loops)-1]
+			}
+		case "PS":
+			p.paused = true
+		default:
+			if p.defining != 0 && mnemonic != "XT" {
+				p.definition = append(p.definition, mnemonic+argument)
+			} else if response, ok := s.respond(a, mnemonic, argument); ok {
+				s.output = append(s.output, response+CR...)
+			} else if !s.applyProgram(a, mnemonic, argument, at) {
+				s.apply(a, mnemonic, argument, at)
+			}
+			if mnemonic == "XR" || mnemonic == "XRP" {
+				continue
+			}
+		}
+		if !p.running() {
+			break
+		}
+		p.pc++
+		a.advance(now)
+	}
+	if !p.running() && p.buffer != nil && a.motion == nil {
+		p.abort(false)
+		if p.runStatus == 5 {
+			p.runStatus = 0
+		}
+	}
+}
+
+/*
+Returns true if the trigger inputs match the pattern of
+1 (high), 0 (low) and X (ignored) characters
+*/
+func (a *axis) triggersMatch(pattern string) bool {
+	for i := 0; i < len(pattern) && i < len(a.triggers); i++ {
+		if pattern[i] != 'X' && (pattern[i] == '1') != a.triggers[i] {
+			return false
+		}
+	}
+	return true
+}
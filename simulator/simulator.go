@@ -0,0 +1,796 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CR string = "\r"
+)
+
+/*
+Command mnemonics understood by the simulator, sorted
+from the longest to the shortest so that the prefix
+matching picks the most specific command
+*/
+var mnemonics = []string{
+	"CMDDIR", "MPA", "MPI", "FSA", "FSB", "FSC", "FSD", "FSH", "OSA", "OSB", "OSC", "OSD", "OSH", "SSE", "XRP", "XSD", "XSR", "XSS",
+	"CG", "DB", "DW", "ER", "FS", "GH", "MN", "MC", "MR", "PR", "PX", "PZ", "PS", "RA", "RB", "RC", "RV",
+	"ST", "W3", "LD", "IS", "TS", "TR", "XC", "XD", "XE", "XR", "XT", "XU",
+	"A", "C", "D", "G", "H", "K", "L", "N", "O", "R", "S", "T", "V", "Y", "Z", "%",
+}
+
+/*
+Valid motor resolutions accepted by the MR command
+*/
+var resolutions = []int{
+	200, 400, 1000, 2000, 5000, 10000, 12800, 18000, 20000, 21600,
+	25000, 25400, 25600, 36000, 50000, 50800, 278528, 425984,
+	507904, 614400, 655360, 819200, 1024000,
+}
+
+type Options struct {
+	Addresses  []uint
+	PartNumber string
+	Clock      func() time.Time
+}
+
+type Simulator struct {
+	axes       map[uint]*axis
+	addresses  []uint
+	partNumber string
+	clock      func() time.Time
+	connected  bool
+	input      []byte
+	output     []byte
+	mutex      sync.Mutex
+}
+
+/*
+Creates a new in-process simulator of a daisy chain of
+OEM750X drives that satisfies the unicomm.Unicomm interface
+
+Parameters:
+  - options: addresses present on the chain (default is a
+    single drive at address 1), the part number reported by
+    RV and the clock used to advance the motion
+*/
+func New(options Options) *Simulator {
+	if len(options.Addresses) == 0 {
+		options.Addresses = []uint{1}
+	}
+	if options.PartNumber == "" {
+		options.PartNumber = "92-016678-01E"
+	}
+	if options.Clock == nil {
+		options.Clock = time.Now
+	}
+	s := &Simulator{
+		axes:       make(map[uint]*axis),
+		partNumber: options.PartNumber,
+		clock:      options.Clock,
+	}
+	for _, address := range options.Addresses {
+		if _, exists := s.axes[address]; exists {
+			continue
+		}
+		s.axes[address] = newAxis(address)
+		s.addresses = append(s.addresses, address)
+	}
+	sort.Slice(s.addresses, func(i, j int) bool {
+		return s.addresses[i] < s.addresses[j]
+	})
+	return s
+}
+
+/*
+Establishes the simulated connection
+*/
+func (s *Simulator) Connect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.connected {
+		return fmt.Errorf("there is a port already connected")
+	}
+	s.connected = true
+	s.input = nil
+	s.output = nil
+	return nil
+}
+
+/*
+Closes the simulated connection
+*/
+func (s *Simulator) Disconnect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.connected {
+		return fmt.Errorf("there is no port connected")
+	}
+	s.connected = false
+	return nil
+}
+
+/*
+Returns true if the simulator is connected
+*/
+func (s *Simulator) IsConnected() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.connected
+}
+
+/*
+Reads up to size bytes that the drives have sent
+*/
+func (s *Simulator) Read(size uint) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.connected {
+		return nil, fmt.Errorf("there is no port connected")
+	}
+	s.advance()
+	n := min(int(size), len(s.output))
+	data := append([]byte(nil), s.output[:n]...)
+	s.output = s.output[n:]
+	return data, nil
+}
+
+/*
+Reads the bytes sent by the drives until the delimiter
+is found. Returns a timeout error with the pending bytes
+if the delimiter was never sent
+*/
+func (s *Simulator) ReadUntil(delimiter string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.connected {
+		return nil, fmt.Errorf("there is no port connected")
+	}
+	s.advance()
+	index := strings.Index(string(s.output), delimiter)
+	if index < 0 {
+		data := s.output
+		s.output = nil
+		return data, fmt.Errorf("read until timeout")
+	}
+	end := index + len(delimiter)
+	data := append([]byte(nil), s.output[:end]...)
+	s.output = s.output[end:]
+	return data, nil
+}
+
+/*
+Sends bytes to the drives. Every complete command is
+echoed and executed by the addressed drives
+*/
+func (s *Simulator) Write(message []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.connected {
+		return fmt.Errorf("there is no port connected")
+	}
+	s.advance()
+	s.input = append(s.input, message...)
+	for {
+		index := strings.Index(string(s.input), CR)
+		if index < 0 {
+			return nil
+		}
+		command := string(s.input[:index])
+		s.input = s.input[index+1:]
+		if strings.HasPrefix(command, "#") {
+			s.output = append(s.output, s.assign(command)+CR...)
+			continue
+		}
+		if s.ignores(command) {
+			s.output = append(s.output, strings.Repeat("&", len(command)+len(CR))...)
+			continue
+		}
+		s.output = append(s.output, command+CR...)
+		if response, ok := s.execute(command); ok {
+			s.output = append(s.output, response+CR...)
+		}
+	}
+}
+
+/*
+Advances every drive up to the current instant, so the
+buffered responses held by a move are sent when it ends
+*/
+func (s *Simulator) advance() {
+	now := s.clock()
+	for _, address := range s.addresses {
+		s.step(s.axes[address], now)
+	}
+}
+
+/*
+Assigns consecutive addresses to the drives in the order of
+the chain (#). Each drive takes the address it receives and
+passes the next one, which returns to the host from the last
+drive
+*/
+func (s *Simulator) assign(command string) string {
+	first, err := strconv.ParseUint(command[1:], 10, 32)
+	if err != nil || first < 1 {
+		return command
+	}
+	axes := make(map[uint]*axis, len(s.addresses))
+	for i, address := range s.addresses {
+		a := s.axes[address]
+		a.address = uint(first) + uint(i)
+		axes[a.address] = a
+		s.addresses[i] = a.address
+	}
+	s.axes = axes
+	return fmt.Sprintf("#%d", uint(first)+uint(len(s.addresses)))
+}
+
+/*
+Returns true if the command goes through a drive that detected
+a communication error, which echoes an & for each byte received
+and ignores every command except %
+*/
+func (s *Simulator) ignores(command string) bool {
+	address, mnemonic, _ := parseCommand(command)
+	if mnemonic == "%" {
+		return false
+	}
+	if address != 0 {
+		a, exists := s.axes[address]
+		return exists && a.framingError
+	}
+	for _, a := range s.axes {
+		if a.framingError {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Splits a command into the device address, the mnemonic
+and its argument. Address zero means all devices
+*/
+func parseCommand(command string) (uint, string, string) {
+	digits := 0
+	for digits < len(command) && '0' <= command[digits] && command[digits] <= '9' {
+		digits++
+	}
+	var address uint
+	if digits > 0 {
+		value, _ := strconv.ParseUint(command[:digits], 10, 32)
+		address = uint(value)
+	}
+	body := command[digits:]
+	for _, mnemonic := range mnemonics {
+		if strings.HasPrefix(body, mnemonic) {
+			return address, mnemonic, body[len(mnemonic):]
+		}
+	}
+	return address, body, ""
+}
+
+/*
+Executes a command on the addressed drives and returns
+the response when the command reports a value
+*/
+func (s *Simulator) execute(command string) (string, bool) {
+	address, mnemonic, argument := parseCommand(command)
+	now := s.clock()
+
+	if address == 0 && mnemonic == "%" {
+		return s.resetCommunication(), true
+	}
+	if address == 0 {
+		for _, target := range s.addresses {
+			s.dispatch(s.axes[target], mnemonic, argument, now)
+		}
+		return "", false
+	}
+	a, exists := s.axes[address]
+	if !exists {
+		return "", false
+	}
+	return s.dispatch(a, mnemonic, argument, now)
+}
+
+/*
+Re-establishes the communication of every drive (%), which
+answer in the order of the chain: *2 for the drive that
+detected a framing error, *0 for the drives after it and *
+for the others
+*/
+func (s *Simulator) resetCommunication() string {
+	var response strings.Builder
+	upstream := false
+	for _, address := range s.addresses {
+		a := s.axes[address]
+		switch {
+		case a.framingError:
+			a.framingError = false
+			upstream = true
+			response.WriteString("*2")
+		case upstream:
+			response.WriteString("*0")
+		default:
+			response.WriteString("*")
+		}
+	}
+	return response.String()
+}
+
+/*
+Executes a command on a single drive. Commands are stored
+while a sequence is being defined and buffered while the
+motor is moving or the command buffer is running, except
+the immediate ones. A buffered report is answered when the
+command buffer reaches it, e.g. PR after the move ends
+*/
+func (s *Simulator) dispatch(a *axis, mnemonic string, argument string, now time.Time) (string, bool) {
+	s.step(a, now)
+	if a.program.defining != 0 && mnemonic != "XT" {
+		a.program.definition = append(a.program.definition, mnemonic+argument)
+		return "", false
+	}
+	if !immediate(mnemonic) && (waits(mnemonic) || a.busy()) {
+		a.enqueue(mnemonic+argument, now)
+		s.step(a, now)
+		return "", false
+	}
+	if response, ok := s.respond(a, mnemonic, argument); ok {
+		return response, true
+	}
+	if !s.applyProgram(a, mnemonic, argument, now) {
+		s.apply(a, mnemonic, argument, now)
+	}
+	s.step(a, now)
+	return "", false
+}
+
+/*
+Returns true if the command executes as soon as it is
+received, even while the drive is busy. Every other command,
+including most reports, is buffered
+*/
+func immediate(mnemonic string) bool {
+	switch mnemonic {
+	case "C", "IS", "K", "R", "RA", "RB", "S", "TS", "W3", "XSR", "Y", "Z", "%":
+		return true
+	}
+	return false
+}
+
+/*
+Returns the response of the status, query and sequence
+status commands
+*/
+func (s *Simulator) respond(a *axis, mnemonic string, argument string) (string, bool) {
+	if response, ok := s.report(a, mnemonic, argument); ok {
+		return response, true
+	}
+	return s.reportProgram(a, mnemonic, argument)
+}
+
+/*
+Returns the response of status and query commands
+*/
+func (s *Simulator) report(a *axis, mnemonic string, argument string) (string, bool) {
+	if argument != "" {
+		return "", false
+	}
+	switch mnemonic {
+	case "V":
+		return "*V" + strconv.FormatFloat(a.velocity, 'f', 2, 64), true
+	case "A":
+		return "*A" + strconv.FormatFloat(a.accel, 'f', 2, 64), true
+	case "D":
+		return fmt.Sprintf("*D%d", a.distance), true
+	case "MR":
+		return fmt.Sprintf("*MR%d", a.resolution), true
+	case "CMDDIR":
+		return fmt.Sprintf("*CMDDIR%d", a.polarity), true
+	case "ST":
+		return fmt.Sprintf("*ST%d", boolToInt(a.shutdown)), true
+	case "PR":
+		return fmt.Sprintf("*%+011d", int(math.Round(a.position))), true
+	case "PX":
+		return fmt.Sprintf("*%+011d", a.encoderPosition()), true
+	case "ER":
+		return fmt.Sprintf("*ER%d", a.encoderResolution), true
+	case "DB":
+		return fmt.Sprintf("*DB%d", a.deadBand), true
+	case "DW":
+		return fmt.Sprintf("*DW%d", a.deadBandWindow), true
+	case "CG":
+		return fmt.Sprintf("*CG%d", a.correctionGain), true
+	case "FS":
+		return "*" + formatDigits(a.absolute, a.encoderSteps, a.positionMaintenance,
+			a.stopOnStall, false, false, false, a.stallDetect), true
+	case "W3":
+		relative := int32(math.Round(a.position - a.moveOrigin))
+		return fmt.Sprintf("*%08X", uint32(relative)), true
+	case "R":
+		return "*" + a.indexerStatus(), true
+	case "RA":
+		cw, ccw := a.limitInputs()
+		return "*" + encodeFlags(a.stoppedCW, a.stoppedCCW, cw, ccw), true
+	case "RB":
+		return "*" + encodeFlags(len(a.program.loops) > 0, a.program.paused, a.shutdown, a.triggerActive()), true
+	case "RC":
+		return "*" + encodeFlags(a.stall, a.homeFailed, a.postMoveLoss, a.staticLoss), true
+	case "IS":
+		return "*" + a.inputStatus(), true
+	case "TS":
+		return "*" + formatDigits(a.triggers[:]...), true
+	case "RV":
+		return "*" + s.partNumber, true
+	case "%":
+		if a.framingError {
+			a.framingError = false
+			return "*2", true
+		}
+		return "*", true
+	}
+	return "", false
+}
+
+/*
+Applies a set-up or motion command to the drive
+*/
+func (s *Simulator) apply(a *axis, mnemonic string, argument string, now time.Time) {
+	switch mnemonic {
+	case "V":
+		if value, err := strconv.ParseFloat(argument, 64); err == nil && 0.01 <= value && value <= 50 {
+			a.velocity = value
+		}
+	case "A":
+		if value, err := strconv.ParseFloat(argument, 64); err == nil && 0.01 <= value && value <= 999 {
+			a.accel = value
+		}
+	case "D":
+		if value, err := strconv.Atoi(argument); err == nil {
+			a.distance = value
+			if value != 0 && !a.absolute {
+				a.direction = math.Copysign(1, float64(value))
+			}
+		}
+	case "MR":
+		if value, err := strconv.Atoi(argument); err == nil {
+			for _, valid := range resolutions {
+				if value == valid {
+					a.resolution = value
+				}
+			}
+		}
+	case "CMDDIR":
+		if argument == "0" || argument == "1" {
+			a.polarity = int(argument[0] - '0')
+		}
+	case "SSE":
+		if argument == "0" || argument == "1" {
+			a.errorChecking = argument == "1"
+		}
+	case "ST":
+		if argument == "0" || argument == "1" {
+			a.shutdown = argument == "1"
+			if a.shutdown {
+				a.kill(now)
+			}
+		}
+	case "LD":
+		if value, err := strconv.Atoi(argument); err == nil && 0 <= value && value <= 3 {
+			a.limits = value
+		}
+	case "MN":
+		a.continuous = false
+	case "MC":
+		a.continuous = true
+	case "MPA":
+		a.absolute = true
+	case "MPI":
+		a.absolute = false
+	case "FSA":
+		if argument == "0" || argument == "1" {
+			a.absolute = argument == "1"
+		}
+	case "FSB":
+		if argument == "0" || argument == "1" {
+			a.encoderSteps = argument == "1"
+		}
+	case "FSC":
+		if argument == "0" || argument == "1" {
+			a.positionMaintenance = argument == "1"
+		}
+	case "FSH":
+		if argument == "0" || argument == "1" {
+			a.stallDetect = argument == "1"
+		}
+	case "ER":
+		if value, err := strconv.Atoi(argument); err == nil && 1 <= value && value <= 50000 {
+			a.encoderResolution = value
+		}
+	case "DB":
+		if value, err := strconv.Atoi(argument); err == nil && 0 <= value && value <= 999999999 {
+			a.deadBand = value
+		}
+	case "DW":
+		if value, err := strconv.Atoi(argument); err == nil && 0 <= value && value <= 999999999 {
+			a.deadBandWindow = value
+		}
+	case "CG":
+		if value, err := strconv.Atoi(argument); err == nil && 1 <= value && value <= 8 {
+			a.correctionGain = value
+		}
+	case "FSD":
+		if argument == "0" || argument == "1" {
+			a.stopOnStall = argument == "1"
+		}
+	case "OSB":
+		if argument == "0" || argument == "1" {
+			a.backUpHome = argument == "1"
+		}
+	case "OSD":
+		if argument == "0" || argument == "1" {
+			a.homeIndex = argument == "1"
+		}
+	case "PZ":
+		if a.motion != nil {
+			a.motion.origin -= a.position
+		}
+		a.moveOrigin -= a.position
+		a.position = 0
+		a.encoderSlip = 0
+	case "H":
+		switch argument {
+		case "+":
+			a.direction = 1
+		case "-":
+			a.direction = -1
+		case "":
+			a.direction = -a.direction
+		}
+	case "G":
+		a.start(now)
+	case "GH":
+		direction := 1.0
+		if strings.HasPrefix(argument, "-") {
+			direction = -1
+		}
+		speed, err := strconv.ParseFloat(strings.TrimLeft(argument, "+-"), 64)
+		if err == nil && 0.01 <= speed && speed <= 50 {
+			a.home(now, direction, speed)
+		}
+	case "S":
+		a.program.abort(true)
+		a.stop(now)
+	case "K":
+		a.program.abort(true)
+		a.kill(now)
+	case "Z":
+		a.program.abort(false)
+		a.reset()
+	}
+}
+
+/*
+Returns true while the motor is moving or the command
+buffer has pending commands
+*/
+func (a *axis) busy() bool {
+	return a.motion != nil || a.program.running()
+}
+
+/*
+Returns the indexer status character reported by R
+*/
+func (a *axis) indexerStatus() string {
+	busy := a.busy()
+	switch {
+	case busy && a.attention:
+		return "C"
+	case busy:
+		return "B"
+	case a.attention:
+		return "S"
+	}
+	return "R"
+}
+
+/*
+Returns the simulated drive for the address or nil
+when the address is not present on the chain
+*/
+func (s *Simulator) drive(address uint) *axis {
+	a, exists := s.axes[address]
+	if !exists {
+		return nil
+	}
+	s.step(a, s.clock())
+	return a
+}
+
+/*
+Gets the absolute position of the simulated motor in steps
+*/
+func (s *Simulator) Position(address uint) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		return int(math.Round(a.position))
+	}
+	return 0
+}
+
+/*
+Returns true while the simulated motor is moving
+*/
+func (s *Simulator) IsMoving(address uint) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		return a.motion != nil
+	}
+	return false
+}
+
+/*
+Forces the state of the CW and CCW limit inputs
+*/
+func (s *Simulator) SetLimitSwitches(address uint, cw bool, ccw bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		a.forcedCW = cw
+		a.forcedCCW = ccw
+	}
+}
+
+/*
+Places end-of-travel limit switches at the given absolute
+positions in steps. The limit reached while moving in the
+positive direction depends on the commanded direction polarity
+*/
+func (s *Simulator) SetTravel(address uint, minimum int, maximum int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		a.travelMin = float64(minimum)
+		a.travelMax = float64(maximum)
+		a.hasTravel = true
+	}
+}
+
+/*
+Sets the level of the trigger inputs 1 to 3, where true
+means the input is high (opened)
+*/
+func (s *Simulator) SetTriggers(address uint, triggers [3]bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		a.triggers = triggers
+	}
+}
+
+/*
+Simulates a framing error on the bytes received by the drive.
+The error is only detected when error checking is enabled
+(SSE1), then the drive ignores the commands until %
+*/
+func (s *Simulator) InjectFramingError(address uint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil && a.errorChecking {
+		a.framingError = true
+	}
+}
+
+/*
+Places the home switch at the given absolute position in steps
+*/
+func (s *Simulator) SetHomePosition(address uint, position int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		a.homePos = float64(position)
+		a.hasHome = true
+	}
+}
+
+/*
+Places the encoder Z channel pulse at the given absolute
+position in steps. It is the final home reference when the
+drive is homed with OSD1, OSB1 and encoder step mode
+*/
+func (s *Simulator) SetEncoderIndex(address uint, position int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		a.indexPos = float64(position)
+		a.hasIndex = true
+	}
+}
+
+/*
+Places mechanical hard stops at the given absolute positions
+in steps. The motor stalls against them, which is reported
+by RC when stall detection is enabled (FSH1) in encoder step
+mode, and the move is stopped with FSD1
+*/
+func (s *Simulator) SetHardStops(address uint, minimum int, maximum int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		a.hardMin = float64(minimum)
+		a.hardMax = float64(maximum)
+		a.hasHardStops = true
+	}
+}
+
+/*
+Returns the programmable outputs 1 and 2 of the drive
+*/
+func (s *Simulator) Outputs(address uint) [2]bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		return a.outputs
+	}
+	return [2]bool{}
+}
+
+/*
+Makes the encoder lag behind the motor by the given number
+of encoder steps, as a missed step or a slipping coupling
+would do. PZ zeroes both positions and removes the slip
+*/
+func (s *Simulator) SetEncoderSlip(address uint, steps int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a := s.drive(address); a != nil {
+		a.encoderSlip = -float64(steps)
+	}
+}
+
+/*
+Formats flags as a string of '0' and '1' digits
+*/
+func formatDigits(flags ...bool) string {
+	digits := make([]byte, len(flags))
+	for i, flag := range flags {
+		digits[i] = byte('0' + boolToInt(flag))
+	}
+	return string(digits)
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
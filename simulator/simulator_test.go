@@ -0,0 +1,356 @@
+package simulator_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	oem750x "github.com/devicehub-go/parker-oem750x"
+	"github.com/devicehub-go/parker-oem750x/protocol"
+	"github.com/devicehub-go/parker-oem750x/simulator"
+)
+
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func connect(t *testing.T, sim *simulator.Simulator) *protocol.OEM750x {
+	parker := oem750x.NewWithCommunication(sim)
+	if err := parker.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { parker.Disconnect() })
+	return parker
+}
+
+func TestSetpoints(t *testing.T) {
+	parker := connect(t, simulator.New(simulator.Options{}))
+
+	var channel uint = 1
+	if err := parker.SetTargetVelocity(channel, 2.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetAcceleration(channel, 12.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetDistance(channel, -1200); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetResolution(channel, 50000); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetPolarity(channel, protocol.Normal); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetShutdown(channel, true); err != nil {
+		t.Fatal(err)
+	}
+
+	velocity, err := parker.GetTargetVelocity(channel)
+	if err != nil || velocity != 2.5 {
+		t.Fatalf("velocity = %v, %v", velocity, err)
+	}
+	acceleration, err := parker.GetTargetAcceleration(channel)
+	if err != nil || acceleration != 12.5 {
+		t.Fatalf("acceleration = %v, %v", acceleration, err)
+	}
+	distance, err := parker.GetTargetDistance(channel)
+	if err != nil || distance != -1200 {
+		t.Fatalf("distance = %v, %v", distance, err)
+	}
+	resolution, err := parker.GetResolution(channel)
+	if err != nil || resolution != 50000 {
+		t.Fatalf("resolution = %v, %v", resolution, err)
+	}
+	polarity, err := parker.GetPolarity(channel)
+	if err != nil || polarity != int(protocol.Normal) {
+		t.Fatalf("polarity = %v, %v", polarity, err)
+	}
+	shutdown, err := parker.GetShutdown(channel)
+	if err != nil || shutdown != 1 {
+		t.Fatalf("shutdown = %v, %v", shutdown, err)
+	}
+}
+
+func TestReadings(t *testing.T) {
+	parker := connect(t, simulator.New(simulator.Options{
+		Addresses:  []uint{4},
+		PartNumber: "92-016678-01E",
+	}))
+
+	var channel uint = 4
+	partNumber, err := parker.GetPartNumber(channel)
+	if err != nil || partNumber != "*92-016678-01E" {
+		t.Fatalf("part number = %q, %v", partNumber, err)
+	}
+	status, err := parker.GetIndexerStatus(channel)
+	if err != nil || status != protocol.IndexerReady {
+		t.Fatalf("indexer status = %q, %v", status, err)
+	}
+	limits, err := parker.ReadLimitStatus(channel)
+	if err != nil || limits != (protocol.LimitStatus{}) {
+		t.Fatalf("limits status = %q, %v", limits, err)
+	}
+	position, err := parker.GetAbsolutePosition(channel)
+	if err != nil || position != 0 {
+		t.Fatalf("absolute position = %d, %v", position, err)
+	}
+	position, err = parker.GetRelativePosition(channel)
+	if err != nil || position != 0 {
+		t.Fatalf("relative position = %d, %v", position, err)
+	}
+	if _, err := parker.GetAbsolutePosition(2); err == nil {
+		t.Fatal("expected an error for a missing address")
+	}
+}
+
+func TestMoveAdvancesPosition(t *testing.T) {
+	clock := &manualClock{now: time.Unix(0, 0)}
+	sim := simulator.New(simulator.Options{Clock: clock.Now})
+	parker := connect(t, sim)
+
+	var channel uint = 1
+	if err := parker.SetNormalMode(channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetIncrementalMode(channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetResolution(channel, 25000); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetVelocity(channel, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetAcceleration(channel, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetDistance(channel, -25000); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(channel); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	status, err := parker.GetIndexerStatus(channel)
+	if err != nil || status != protocol.IndexerBusy {
+		t.Fatalf("indexer status = %q, %v", status, err)
+	}
+	relative, err := parker.GetRelativePosition(channel)
+	if err != nil || relative >= 0 || relative <= -25000 {
+		t.Fatalf("relative position = %d, %v", relative, err)
+	}
+
+	clock.Advance(time.Second)
+	status, err = parker.GetIndexerStatus(channel)
+	if err != nil || status != protocol.IndexerReady {
+		t.Fatalf("indexer status = %q, %v", status, err)
+	}
+	position, err := parker.GetAbsolutePosition(channel)
+	if err != nil || position != -25000 {
+		t.Fatalf("absolute position = %d, %v", position, err)
+	}
+
+	if err := parker.SetAbsoluteMode(channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetDistance(channel, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(channel); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(5 * time.Second)
+	if position := sim.Position(channel); position != 1000 {
+		t.Fatalf("absolute position = %d", position)
+	}
+}
+
+func TestContinuousMoveStopsAtLimit(t *testing.T) {
+	clock := &manualClock{now: time.Unix(0, 0)}
+	sim := simulator.New(simulator.Options{Clock: clock.Now})
+	sim.SetTravel(1, -5000, 5000)
+	parker := connect(t, sim)
+
+	var channel uint = 1
+	if err := parker.SetContinuosMode(channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetDirection(channel, protocol.Forward); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(channel); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Second)
+
+	status, err := parker.GetIndexerStatus(channel)
+	if err != nil || status != protocol.IndexerReadyAttention {
+		t.Fatalf("indexer status = %q, %v", status, err)
+	}
+	limits, err := parker.ReadLimitStatus(channel)
+	if err != nil || !limits.LastMoveStoppedByCW || !limits.CWActive || limits.String() != "1010" {
+		t.Fatalf("limits status = %q, %v", limits, err)
+	}
+	if position := sim.Position(channel); position != 5000 {
+		t.Fatalf("absolute position = %d", position)
+	}
+
+	if err := parker.Go(channel); err != nil {
+		t.Fatal(err)
+	}
+	if sim.IsMoving(channel) {
+		t.Fatal("motor moved into an active limit")
+	}
+}
+
+func TestStopDecelerates(t *testing.T) {
+	clock := &manualClock{now: time.Unix(0, 0)}
+	sim := simulator.New(simulator.Options{Clock: clock.Now})
+	parker := connect(t, sim)
+
+	var channel uint = 1
+	if err := parker.SetContinuosMode(channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.Go(channel); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Second)
+	if err := parker.Stop(channel); err != nil {
+		t.Fatal(err)
+	}
+	stoppedAt := sim.Position(channel)
+	if !sim.IsMoving(channel) {
+		t.Fatal("expected the motor to decelerate")
+	}
+	clock.Advance(time.Second)
+	if sim.IsMoving(channel) || sim.Position(channel) <= stoppedAt {
+		t.Fatalf("motor did not decelerate to a stop, position %d", sim.Position(channel))
+	}
+}
+
+func TestGoHomeHard(t *testing.T) {
+	sim := simulator.New(simulator.Options{Addresses: []uint{3}})
+	sim.SetTravel(3, -2000, 1000000)
+	parker := connect(t, sim)
+
+	var channel uint = 3
+	if err := parker.SetResolution(channel, 200); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetTargetAcceleration(channel, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.SetPolarity(channel, protocol.Inverted); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := parker.GoHomeHard(ctx, channel, 20); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	position, err := parker.GetAbsolutePosition(channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position < 0 || position > 10 {
+		t.Fatalf("absolute position after homing = %d", position)
+	}
+}
+
+func TestSequenceRunsFromBuffer(t *testing.T) {
+	clock := &manualClock{now: time.Unix(0, 0)}
+	sim := simulator.New(simulator.Options{Clock: clock.Now})
+	parker := connect(t, sim)
+
+	var channel uint = 1
+	sequence := protocol.NewSequence().Loop(1).Distance(25000).Go().Delay(1).Go().EndLoop()
+	if err := parker.DefineSequence(channel, 1, sequence); err != nil {
+		t.Fatal(err)
+	}
+	if err := parker.RunSequence(channel, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(2 * time.Second)
+	status, err := parker.GetIndexerStatus(channel)
+	if err != nil || status != protocol.IndexerBusy {
+		t.Fatalf("indexer status during delay = %q, %v", status, err)
+	}
+	if position := sim.Position(channel); position != 25000 || sim.IsMoving(channel) {
+		t.Fatalf("position during delay = %d", position)
+	}
+	run, err := parker.GetSequenceRunStatus(channel)
+	if err != nil || run != protocol.SequenceRunInLoop {
+		t.Fatalf("run status = %v, %v", run, err)
+	}
+	execution, err := parker.GetExecutionStatus(channel)
+	if err != nil || !execution.LoopActive {
+		t.Fatalf("execution status during loop = %s, %v", execution, err)
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+	if position := sim.Position(channel); position != 50000 {
+		t.Fatalf("position after sequence = %d", position)
+	}
+	run, err = parker.GetSequenceRunStatus(channel)
+	if err != nil || run != protocol.SequenceRunOK {
+		t.Fatalf("run status = %v, %v", run, err)
+	}
+	execution, err = parker.GetExecutionStatus(channel)
+	if err != nil || execution.LoopActive {
+		t.Fatalf("execution status after loop = %s, %v", execution, err)
+	}
+}
+
+func TestBufferedCommandsWaitForMove(t *testing.T) {
+	clock := &manualClock{now: time.Unix(0, 0)}
+	sim := simulator.New(simulator.Options{Clock: clock.Now})
+	if err := sim.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	exchange := func(command string) []string {
+		if err := sim.Write([]byte(command + "\r")); err != nil {
+			t.Fatal(err)
+		}
+		var lines []string
+		for {
+			line, err := sim.ReadUntil("\r")
+			if err != nil {
+				return lines
+			}
+			lines = append(lines, string(line))
+		}
+	}
+
+	exchange("1G")
+	if lines := exchange("1PR"); len(lines) != 1 {
+		t.Fatalf("PR answered during the move: %q", lines)
+	}
+	if lines := exchange("1V2"); len(lines) != 1 {
+		t.Fatalf("unexpected response to V: %q", lines)
+	}
+	if lines := exchange("1R"); len(lines) != 2 || lines[1] != "*B\r" {
+		t.Fatalf("R during the move = %q", lines)
+	}
+
+	clock.Advance(2 * time.Second)
+	if lines := exchange("1R"); len(lines) != 3 || lines[0] != "*+0000025000\r" || lines[2] != "*R\r" {
+		t.Fatalf("responses after the move = %q", lines)
+	}
+	if lines := exchange("1V"); len(lines) != 2 || lines[1] != "*V2.00\r" {
+		t.Fatalf("velocity after the move = %q", lines)
+	}
+}